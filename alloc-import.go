@@ -0,0 +1,271 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// allocationEntry is a single row of token distribution list
+type allocationEntry struct {
+	Address common.Address
+	Amount  *big.Int
+	Label   string
+	// Source is a file name and line (or index) of the entry, used in error messages
+	Source string
+}
+
+type allocationLabelTotal struct {
+	Label    string   `json:"label"`
+	Accounts int      `json:"accounts"`
+	Total    *big.Int `json:"total"`
+}
+
+// parseChecksumAddress parses hex address, mixed-case addresses must have a valid EIP-55 checksum
+func parseChecksumAddress(value string) (common.Address, error) {
+	value = strings.TrimSpace(value)
+	if !common.IsHexAddress(value) {
+		return common.Address{}, fmt.Errorf("bad address (%s)", value)
+	}
+	address := common.HexToAddress(value)
+	hex := strings.TrimPrefix(strings.TrimPrefix(value, "0x"), "0X")
+	if hex != strings.ToLower(hex) && hex != strings.ToUpper(hex) && "0x"+hex != address.Hex() {
+		return common.Address{}, fmt.Errorf("bad address checksum (%s), expected %s", value, address.Hex())
+	}
+	return address, nil
+}
+
+// parseAllocationAmount parses amount in CHZ (decimal, up to 18 fraction digits, "," and "_" separators are allowed),
+// hex values are treated as amounts in wei like in the faucet config
+func parseAllocationAmount(value string) (*big.Int, error) {
+	value = strings.TrimSpace(value)
+	if strings.HasPrefix(value, "0x") || strings.HasPrefix(value, "0X") {
+		return hexutil.DecodeBig(value)
+	}
+	value = strings.NewReplacer(",", "", "_", "").Replace(value)
+	whole, fraction, _ := strings.Cut(value, ".")
+	if whole+fraction == "" {
+		return nil, fmt.Errorf("amount is empty")
+	}
+	if len(fraction) > 18 {
+		return nil, fmt.Errorf("too many fraction digits in amount (%s)", value)
+	}
+	result, ok := new(big.Int).SetString(whole+fraction+strings.Repeat("0", 18-len(fraction)), 10)
+	if !ok || result.Sign() < 0 {
+		return nil, fmt.Errorf("failed to parse amount (%s)", value)
+	}
+	return result, nil
+}
+
+// formatAmount formats non-negative amount in wei as CHZ with thousand separators
+func formatAmount(wei *big.Int) string {
+	whole, fraction := new(big.Int).QuoRem(wei, big.NewInt(1e18), new(big.Int))
+	digits := whole.String()
+	var sb strings.Builder
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteRune(d)
+	}
+	if fraction.Sign() != 0 {
+		sb.WriteString("." + strings.TrimRight(fmt.Sprintf("%018s", fraction.String()), "0"))
+	}
+	return sb.String() + " CHZ"
+}
+
+func readAllocationCSV(filePath string, r io.Reader) ([]allocationEntry, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse allocation file (%s): %w", filePath, err)
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+	columns := map[string]int{"label": -1}
+	for i, name := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"address", "amount"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("allocation file (%s) has no %s column", filePath, name)
+		}
+	}
+	var result []allocationEntry
+	for i, record := range records[1:] {
+		source := fmt.Sprintf("%s:%d", filePath, i+2)
+		if len(record) <= columns["address"] || len(record) <= columns["amount"] {
+			return nil, fmt.Errorf("%s: not enough columns", source)
+		}
+		var label string
+		if c := columns["label"]; c >= 0 && c < len(record) {
+			label = strings.TrimSpace(record[c])
+		}
+		entry, err := newAllocationEntry(record[columns["address"]], record[columns["amount"]], label, source)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, entry)
+	}
+	return result, nil
+}
+
+func readAllocationJSON(filePath string, r io.Reader) ([]allocationEntry, error) {
+	var rows []struct {
+		Address string `json:"address"`
+		Amount  string `json:"amount"`
+		Label   string `json:"label"`
+	}
+	if err := json.NewDecoder(r).Decode(&rows); err != nil {
+		return nil, fmt.Errorf("failed to parse allocation file (%s): %w", filePath, err)
+	}
+	var result []allocationEntry
+	for i, row := range rows {
+		entry, err := newAllocationEntry(row.Address, row.Amount, row.Label, fmt.Sprintf("%s[%d]", filePath, i))
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, entry)
+	}
+	return result, nil
+}
+
+func newAllocationEntry(rawAddress, rawAmount, label, source string) (allocationEntry, error) {
+	address, err := parseChecksumAddress(rawAddress)
+	if err != nil {
+		return allocationEntry{}, fmt.Errorf("%s: %w", source, err)
+	}
+	amount, err := parseAllocationAmount(rawAmount)
+	if err != nil {
+		return allocationEntry{}, fmt.Errorf("%s: %w", source, err)
+	}
+	if label == "" {
+		label = "unlabeled"
+	}
+	return allocationEntry{Address: address, Amount: amount, Label: label, Source: source}, nil
+}
+
+// readAllocationFiles reads token distribution lists, format is detected by the file extension (.csv or .json)
+func readAllocationFiles(filePaths []string) ([]allocationEntry, error) {
+	var result []allocationEntry
+	for _, filePath := range filePaths {
+		f, err := os.Open(filePath)
+		if err != nil {
+			return nil, err
+		}
+		var entries []allocationEntry
+		switch strings.ToLower(filepath.Ext(filePath)) {
+		case ".csv":
+			entries, err = readAllocationCSV(filePath, f)
+		case ".json":
+			entries, err = readAllocationJSON(filePath, f)
+		default:
+			err = fmt.Errorf("unknown allocation file format (%s), only .csv and .json are supported", filePath)
+		}
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, entries...)
+	}
+	return result, nil
+}
+
+// validateAllocations looks for duplicated addresses and collisions with system addresses, all issues are reported at once
+func validateAllocations(entries []allocationEntry) error {
+	var issues []string
+	seen := make(map[common.Address]allocationEntry)
+	for _, entry := range entries {
+		if c, ok := systemContractByAddress(entry.Address); ok {
+			issues = append(issues, fmt.Sprintf("%s: address %s collides with system contract %s", entry.Source, entry.Address.Hex(), c.Name))
+		} else if entry.Address == intermediarySystemAddress {
+			issues = append(issues, fmt.Sprintf("%s: address %s collides with intermediary system address", entry.Source, entry.Address.Hex()))
+		}
+		if prev, ok := seen[entry.Address]; ok {
+			issues = append(issues, fmt.Sprintf("%s: duplicated address %s (first seen at %s)", entry.Source, entry.Address.Hex(), prev.Source))
+			continue
+		}
+		seen[entry.Address] = entry
+	}
+	if len(issues) > 0 {
+		return fmt.Errorf("invalid allocations:\n - %s", strings.Join(issues, "\n - "))
+	}
+	return nil
+}
+
+func allocationLabelTotals(entries []allocationEntry) []allocationLabelTotal {
+	totals := make(map[string]*allocationLabelTotal)
+	var labels []string
+	for _, entry := range entries {
+		total, ok := totals[entry.Label]
+		if !ok {
+			total = &allocationLabelTotal{Label: entry.Label, Total: big.NewInt(0)}
+			totals[entry.Label] = total
+			labels = append(labels, entry.Label)
+		}
+		total.Accounts++
+		total.Total.Add(total.Total, entry.Amount)
+	}
+	sort.Strings(labels)
+	var result []allocationLabelTotal
+	for _, label := range labels {
+		result = append(result, *totals[label])
+	}
+	return result
+}
+
+func printAllocationLabelTotals(w io.Writer, totals []allocationLabelTotal) {
+	grandTotal := big.NewInt(0)
+	accounts := 0
+	fmt.Fprintf(w, "%-32s %10s %36s\n", "label", "accounts", "total")
+	for _, t := range totals {
+		fmt.Fprintf(w, "%-32s %10d %36s\n", t.Label, t.Accounts, formatAmount(t.Total))
+		grandTotal.Add(grandTotal, t.Total)
+		accounts += t.Accounts
+	}
+	fmt.Fprintf(w, "%-32s %10d %36s\n", "total", accounts, formatAmount(grandTotal))
+}
+
+func runImportAllocCommand(args []string) error {
+	flags := flag.NewFlagSet("import-alloc", flag.ContinueOnError)
+	format := flags.String("format", "text", "report format: text or json")
+	var files stringListFlag
+	flags.Var(&files, "file", "CSV or JSON file with address, amount and label columns (can be repeated)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("allocation file is required")
+	}
+	entries, err := readAllocationFiles(files)
+	if err != nil {
+		return err
+	}
+	if err := validateAllocations(entries); err != nil {
+		return err
+	}
+	totals := allocationLabelTotals(entries)
+	switch *format {
+	case "json":
+		result, _ := json.MarshalIndent(totals, "", "  ")
+		os.Stdout.Write(append(result, '\n'))
+	case "text":
+		printAllocationLabelTotals(os.Stdout, totals)
+	default:
+		return fmt.Errorf("unknown report format (%s)", *format)
+	}
+	return nil
+}
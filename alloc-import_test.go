@@ -0,0 +1,88 @@
+package main
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func TestParseAllocationAmount(t *testing.T) {
+	ether := func(v int64) *big.Int {
+		return new(big.Int).Mul(big.NewInt(v), big.NewInt(1e18))
+	}
+	for _, test := range []struct {
+		value    string
+		expected *big.Int
+		err      bool
+	}{
+		{value: "1", expected: ether(1)},
+		{value: " 1000 ", expected: ether(1000)},
+		{value: "1,000,000", expected: ether(1_000_000)},
+		{value: "1_000", expected: ether(1000)},
+		{value: "0.5", expected: big.NewInt(5e17)},
+		{value: ".5", expected: big.NewInt(5e17)},
+		{value: "1.", expected: ether(1)},
+		{value: "0.000000000000000001", expected: big.NewInt(1)},
+		{value: "0", expected: big.NewInt(0)},
+		{value: "0x10", expected: big.NewInt(16)},
+		{value: "0X10", expected: big.NewInt(16)},
+		{value: "", err: true},
+		{value: "   ", err: true},
+		{value: ".", err: true},
+		{value: ",", err: true},
+		{value: "0.0000000000000000001", err: true},
+		{value: "-1", err: true},
+		{value: "1.-5", err: true},
+		{value: "1.2.3", err: true},
+		{value: "abc", err: true},
+		{value: "0x", err: true},
+		{value: "0xzz", err: true},
+	} {
+		result, err := parseAllocationAmount(test.value)
+		if test.err {
+			if err == nil {
+				t.Errorf("parseAllocationAmount(%q): expected error, got %s", test.value, result)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseAllocationAmount(%q): %v", test.value, err)
+		} else if result.Cmp(test.expected) != 0 {
+			t.Errorf("parseAllocationAmount(%q): expected %s, got %s", test.value, test.expected, result)
+		}
+	}
+}
+
+func TestParseChecksumAddress(t *testing.T) {
+	const checksummed = "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"
+	for _, test := range []struct {
+		value    string
+		expected common.Address
+		err      bool
+	}{
+		{value: checksummed, expected: common.HexToAddress(checksummed)},
+		{value: " " + checksummed + " ", expected: common.HexToAddress(checksummed)},
+		{value: "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", expected: common.HexToAddress(checksummed)},
+		{value: "0x5AAEB6053F3E94C9B9A09F33669435E7EF1BEAED", expected: common.HexToAddress(checksummed)},
+		{value: "5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", expected: common.HexToAddress(checksummed)},
+		{value: "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD", err: true},
+		{value: "0x5aaeb6053f3e94c9b9a09f33669435e7ef1bea", err: true},
+		{value: "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaedaa", err: true},
+		{value: "0xzaaeb6053f3e94c9b9a09f33669435e7ef1beaed", err: true},
+		{value: "", err: true},
+	} {
+		result, err := parseChecksumAddress(test.value)
+		if test.err {
+			if err == nil {
+				t.Errorf("parseChecksumAddress(%q): expected error, got %s", test.value, result.Hex())
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseChecksumAddress(%q): %v", test.value, err)
+		} else if result != test.expected {
+			t.Errorf("parseChecksumAddress(%q): expected %s, got %s", test.value, test.expected.Hex(), result.Hex())
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+)
+
+func isSystemAddress(address common.Address) bool {
+	_, ok := systemContractByAddress(address)
+	return ok || address == intermediarySystemAddress
+}
+
+// allocMerger merges alloc entries coming from different sources (system contracts, faucet, imported allocations),
+// balances of the same account are added up, while replacing code, storage or nonce is a conflict, system addresses
+// are reserved, other sources can only top up their balances
+type allocMerger struct {
+	alloc     core.GenesisAlloc
+	origins   map[common.Address][]string
+	conflicts []string
+	merges    []string
+}
+
+func newAllocMerger(alloc core.GenesisAlloc) *allocMerger {
+	m := &allocMerger{alloc: alloc, origins: make(map[common.Address][]string)}
+	for address := range alloc {
+		if c, ok := systemContractByAddress(address); ok {
+			m.origins[address] = []string{"system contract " + c.Name}
+		} else if address == intermediarySystemAddress {
+			m.origins[address] = []string{"intermediary system address"}
+		} else {
+			m.origins[address] = []string{"existing genesis"}
+		}
+	}
+	return m
+}
+
+func (m *allocMerger) add(source string, address common.Address, account core.GenesisAccount) {
+	existing, ok := m.alloc[address]
+	if !ok {
+		m.alloc[address] = account
+		m.origins[address] = []string{source}
+		return
+	}
+	origin := strings.Join(m.origins[address], ", ")
+	switch {
+	case isSystemAddress(address) && (len(account.Code) > 0 || len(account.Storage) > 0 || account.Nonce != 0):
+		m.conflicts = append(m.conflicts, fmt.Sprintf("%s: account %s is reserved by %s, only balance can be added", source, address.Hex(), origin))
+		return
+	case len(account.Code) > 0 && !bytes.Equal(existing.Code, account.Code):
+		m.conflicts = append(m.conflicts, fmt.Sprintf("%s: code of account %s from %s can't be replaced", source, address.Hex(), origin))
+		return
+	case account.Nonce != 0 && existing.Nonce != 0 && account.Nonce != existing.Nonce:
+		m.conflicts = append(m.conflicts, fmt.Sprintf("%s: nonce of account %s from %s can't be replaced", source, address.Hex(), origin))
+		return
+	}
+	for slot, value := range account.Storage {
+		if prev, ok := existing.Storage[slot]; ok && prev != value {
+			m.conflicts = append(m.conflicts, fmt.Sprintf("%s: storage slot %s of account %s from %s can't be replaced", source, slot.Hex(), address.Hex(), origin))
+			return
+		}
+	}
+	merged := existing
+	merged.Balance = new(big.Int)
+	if existing.Balance != nil {
+		merged.Balance.Set(existing.Balance)
+	}
+	if account.Balance != nil {
+		merged.Balance.Add(merged.Balance, account.Balance)
+	}
+	if len(account.Code) > 0 {
+		merged.Code = account.Code
+	}
+	if account.Nonce != 0 {
+		merged.Nonce = account.Nonce
+	}
+	if len(account.Storage) > 0 {
+		merged.Storage = make(map[common.Hash]common.Hash, len(existing.Storage)+len(account.Storage))
+		for slot, value := range existing.Storage {
+			merged.Storage[slot] = value
+		}
+		for slot, value := range account.Storage {
+			merged.Storage[slot] = value
+		}
+	}
+	m.alloc[address] = merged
+	m.merges = append(m.merges, fmt.Sprintf("%s: account %s is also funded by %s, balances are added", source, address.Hex(), origin))
+	m.origins[address] = append(m.origins[address], source)
+}
+
+// addFaucetAccounts merges faucet balances of the config, accounts are added in a stable order
+func addFaucetAccounts(m *allocMerger, config genesisConfig) error {
+	for _, key := range sortedAddresses(config.Faucet) {
+		value := config.Faucet[key]
+		balance, ok := new(big.Int).SetString(value[2:], 16)
+		if !ok {
+			return fmt.Errorf("failed to parse number (%s)", value)
+		}
+		source := "faucet"
+		if label, ok := config.FaucetLabels[key]; ok {
+			source = fmt.Sprintf("faucet (%s)", label)
+		}
+		m.add(source, key, core.GenesisAccount{Balance: balance})
+	}
+	return nil
+}
+
+// keptAllocWarnings merges faucet into system accounts of the kept alloc in report-only mode, nothing is applied,
+// so conflicts, merges and balances that differ from the existing genesis are returned as warnings
+func keptAllocWarnings(config genesisConfig, alloc core.GenesisAlloc) ([]string, error) {
+	system := make(core.GenesisAlloc)
+	for address, account := range alloc {
+		if isSystemAddress(address) {
+			system[address] = account
+		}
+	}
+	m := newAllocMerger(system)
+	if err := addFaucetAccounts(m, config); err != nil {
+		return nil, err
+	}
+	var warnings []string
+	for _, message := range append(m.conflicts, m.merges...) {
+		warnings = append(warnings, "faucet isn't applied to the kept alloc, "+message)
+	}
+	for _, address := range sortedAddresses(m.alloc) {
+		if isSystemAddress(address) {
+			continue
+		}
+		expected, existing := m.alloc[address].Balance, alloc[address].Balance
+		if existing == nil {
+			existing = new(big.Int)
+		}
+		if expected.Cmp(existing) != 0 {
+			warnings = append(warnings, fmt.Sprintf("faucet balance of %s differs from the kept alloc (%s in config, %s in genesis)", address.Hex(), expected, existing))
+		}
+	}
+	return warnings, nil
+}
+
+// err returns all conflicts found while merging
+func (m *allocMerger) err() error {
+	if len(m.conflicts) > 0 {
+		return fmt.Errorf("genesis alloc conflicts:\n - %s", strings.Join(m.conflicts, "\n - "))
+	}
+	return nil
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+)
+
+func TestAllocMerger(t *testing.T) {
+	var (
+		user    = common.HexToAddress("0x00000000000000000000000000000000000000aa")
+		slot    = common.HexToHash("0x01")
+		code    = []byte{0x60, 0x00}
+		balance = func(v int64) core.GenesisAccount {
+			return core.GenesisAccount{Balance: big.NewInt(v)}
+		}
+	)
+	type entry struct {
+		source  string
+		address common.Address
+		account core.GenesisAccount
+	}
+	for _, test := range []struct {
+		name      string
+		alloc     core.GenesisAlloc
+		entries   []entry
+		account   common.Address
+		balance   *big.Int
+		conflicts []string
+		merges    int
+	}{
+		{
+			name:    "new account",
+			entries: []entry{{"faucet", user, balance(1)}},
+			balance: big.NewInt(1),
+		},
+		{
+			name:    "balances are added",
+			entries: []entry{{"faucet", user, balance(1)}, {"allocations.csv", user, balance(2)}},
+			balance: big.NewInt(3),
+			merges:  1,
+		},
+		{
+			name:    "existing genesis account is funded",
+			alloc:   core.GenesisAlloc{user: balance(5)},
+			entries: []entry{{"faucet", user, balance(1)}},
+			balance: big.NewInt(6),
+			merges:  1,
+		},
+		{
+			name:    "system contract balance is topped up",
+			alloc:   core.GenesisAlloc{stakingAddress: {Balance: big.NewInt(2), Code: code}},
+			entries: []entry{{"faucet", stakingAddress, balance(1)}},
+			account: stakingAddress,
+			balance: big.NewInt(3),
+			merges:  1,
+		},
+		{
+			name:    "intermediary system address balance is topped up",
+			alloc:   core.GenesisAlloc{intermediarySystemAddress: balance(0)},
+			entries: []entry{{"faucet", intermediarySystemAddress, balance(1)}, {"allocations.csv", intermediarySystemAddress, balance(2)}},
+			account: intermediarySystemAddress,
+			balance: big.NewInt(3),
+			merges:  2,
+		},
+		{
+			name:      "system contract code is reserved",
+			alloc:     core.GenesisAlloc{stakingAddress: {Balance: big.NewInt(0), Code: code}},
+			entries:   []entry{{"allocations.csv", stakingAddress, core.GenesisAccount{Balance: big.NewInt(1), Code: code}}},
+			account:   stakingAddress,
+			balance:   big.NewInt(0),
+			conflicts: []string{"allocations.csv: account " + stakingAddress.Hex() + " is reserved by system contract"},
+		},
+		{
+			name:      "system contract storage is reserved",
+			alloc:     core.GenesisAlloc{stakingAddress: {Balance: big.NewInt(0), Code: code}},
+			entries:   []entry{{"allocations.csv", stakingAddress, core.GenesisAccount{Storage: map[common.Hash]common.Hash{slot: common.HexToHash("0x01")}}}},
+			conflicts: []string{"only balance can be added"},
+		},
+		{
+			name:      "intermediary system address nonce is reserved",
+			alloc:     core.GenesisAlloc{intermediarySystemAddress: balance(0)},
+			entries:   []entry{{"allocations.csv", intermediarySystemAddress, core.GenesisAccount{Nonce: 1}}},
+			conflicts: []string{"is reserved by intermediary system address"},
+		},
+		{
+			name:      "code can't be replaced",
+			entries:   []entry{{"a", user, core.GenesisAccount{Balance: big.NewInt(1), Code: code}}, {"b", user, core.GenesisAccount{Balance: big.NewInt(1), Code: []byte{0x00}}}},
+			balance:   big.NewInt(1),
+			conflicts: []string{"b: code of account " + user.Hex() + " from a can't be replaced"},
+		},
+		{
+			name:    "same code is merged",
+			entries: []entry{{"a", user, core.GenesisAccount{Balance: big.NewInt(1), Code: code}}, {"b", user, core.GenesisAccount{Balance: big.NewInt(1), Code: code}}},
+			balance: big.NewInt(2),
+			merges:  1,
+		},
+		{
+			name:      "nonce can't be replaced",
+			entries:   []entry{{"a", user, core.GenesisAccount{Balance: big.NewInt(1), Nonce: 1}}, {"b", user, core.GenesisAccount{Balance: big.NewInt(1), Nonce: 2}}},
+			balance:   big.NewInt(1),
+			conflicts: []string{"b: nonce of account"},
+		},
+		{
+			name: "storage slot can't be replaced",
+			entries: []entry{
+				{"a", user, core.GenesisAccount{Balance: big.NewInt(1), Storage: map[common.Hash]common.Hash{slot: common.HexToHash("0x01")}}},
+				{"b", user, core.GenesisAccount{Balance: big.NewInt(1), Storage: map[common.Hash]common.Hash{slot: common.HexToHash("0x02")}}},
+			},
+			balance:   big.NewInt(1),
+			conflicts: []string{"b: storage slot " + slot.Hex()},
+		},
+		{
+			name:    "balance of the first source is not mutated",
+			entries: []entry{{"a", user, core.GenesisAccount{}}, {"b", user, balance(2)}},
+			balance: big.NewInt(2),
+			merges:  1,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			alloc := test.alloc
+			if alloc == nil {
+				alloc = make(core.GenesisAlloc)
+			}
+			m := newAllocMerger(alloc)
+			for _, e := range test.entries {
+				m.add(e.source, e.address, e.account)
+			}
+			if len(m.conflicts) != len(test.conflicts) {
+				t.Fatalf("expected %d conflicts, got %q", len(test.conflicts), m.conflicts)
+			}
+			for i, conflict := range test.conflicts {
+				if !strings.Contains(m.conflicts[i], conflict) {
+					t.Errorf("expected conflict %q, got %q", conflict, m.conflicts[i])
+				}
+			}
+			if (m.err() != nil) != (len(test.conflicts) > 0) {
+				t.Errorf("unexpected error: %v", m.err())
+			}
+			if len(m.merges) != test.merges {
+				t.Errorf("expected %d merges, got %q", test.merges, m.merges)
+			}
+			account := test.account
+			if account == (common.Address{}) {
+				account = user
+			}
+			if test.balance != nil && m.alloc[account].Balance.Cmp(test.balance) != 0 {
+				t.Errorf("expected balance %s, got %s", test.balance, m.alloc[account].Balance)
+			}
+		})
+	}
+}
+
+func TestKeptAllocWarnings(t *testing.T) {
+	var (
+		funded  = common.HexToAddress("0x00000000000000000000000000000000000000aa")
+		changed = common.HexToAddress("0x00000000000000000000000000000000000000bb")
+		added   = common.HexToAddress("0x00000000000000000000000000000000000000cc")
+	)
+	alloc := core.GenesisAlloc{
+		stakingAddress: {Balance: big.NewInt(0), Code: []byte{0x60, 0x00}},
+		funded:         {Balance: big.NewInt(16)},
+		changed:        {Balance: big.NewInt(16)},
+	}
+	config := genesisConfig{Faucet: map[common.Address]string{
+		funded:         "0x10",
+		changed:        "0x20",
+		added:          "0x1",
+		stakingAddress: "0x1",
+	}}
+	warnings, err := keptAllocWarnings(config, alloc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"faucet isn't applied to the kept alloc, faucet: account " + stakingAddress.Hex() + " is also funded by system contract",
+		"faucet balance of " + changed.Hex() + " differs from the kept alloc (32 in config, 16 in genesis)",
+		"faucet balance of " + added.Hex() + " differs from the kept alloc (1 in config, 0 in genesis)",
+	}
+	if len(warnings) != len(expected) {
+		t.Fatalf("expected %d warnings, got %q", len(expected), warnings)
+	}
+	for i, warning := range expected {
+		if !strings.Contains(warnings[i], warning) {
+			t.Errorf("expected warning %q, got %q", warning, warnings[i])
+		}
+	}
+	if alloc[funded].Balance.Int64() != 16 || len(alloc) != 3 {
+		t.Errorf("kept alloc is modified")
+	}
+}
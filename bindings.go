@@ -0,0 +1,111 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+)
+
+const bindingsFileName = "systemcontracts.go"
+const addressesFileName = "addresses.go"
+
+// generateBindings creates abigen-style bindings of all system contracts and system address constants,
+// all contracts are bound at once so structs shared by several contracts are declared only once
+func generateBindings(artifactsDir string, pkg string) (map[string][]byte, error) {
+	var types, abis, bytecodes []string
+	var fsigs []map[string]string
+	addresses := &strings.Builder{}
+	fmt.Fprintf(addresses, "// Code generated by create-genesis bindings - DO NOT EDIT.\n\npackage %s\n\n", pkg)
+	fmt.Fprintf(addresses, "import \"github.com/ethereum/go-ethereum/common\"\n\n")
+	fmt.Fprintf(addresses, "// system contract addresses\nvar (\n")
+	for _, c := range systemContracts {
+		rawArtifact, err := readSystemContractArtifact(artifactsDir, c)
+		if err != nil {
+			return nil, err
+		}
+		artifact, err := parseArtifact(rawArtifact)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse artifact of %s: %w", c.Name, err)
+		}
+		types = append(types, c.Name)
+		abis = append(abis, string(artifact.ABI))
+		// system contracts are deployed in genesis only, so deploy methods are not generated
+		bytecodes = append(bytecodes, "")
+		fsigs = append(fsigs, nil)
+		fmt.Fprintf(addresses, "\t%sAddress = common.HexToAddress(%q)\n", c.Name, c.Address.Hex())
+	}
+	fmt.Fprintf(addresses, "\tIntermediarySystemAddress = common.HexToAddress(%q)\n)\n", intermediarySystemAddress.Hex())
+	code, err := bind.Bind(types, abis, bytecodes, fsigs, pkg, bind.LangGo, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	addressesCode, err := format.Source([]byte(addresses.String()))
+	if err != nil {
+		return nil, err
+	}
+	return map[string][]byte{
+		bindingsFileName:  []byte(code),
+		addressesFileName: addressesCode,
+	}, nil
+}
+
+// checkBindings compares generated bindings with files in the directory, missing and drifted files are returned
+func checkBindings(outputDir string, files map[string][]byte) ([]string, []string, error) {
+	var missing, drifted []string
+	for _, name := range []string{bindingsFileName, addressesFileName} {
+		existing, err := os.ReadFile(filepath.Join(outputDir, name))
+		if os.IsNotExist(err) {
+			missing = append(missing, filepath.Join(outputDir, name))
+			continue
+		} else if err != nil {
+			return nil, nil, err
+		}
+		if !bytes.Equal(existing, files[name]) {
+			drifted = append(drifted, filepath.Join(outputDir, name))
+		}
+	}
+	return missing, drifted, nil
+}
+
+func runBindingsCommand(args []string) error {
+	flags := flag.NewFlagSet("bindings", flag.ContinueOnError)
+	artifactsDir := flags.String("artifacts", "", "forge output directory (embedded artifacts are used by default)")
+	outputDir := flags.String("output", "systemcontracts", "output directory of the bindings package")
+	pkg := flags.String("package", "systemcontracts", "package name of the bindings")
+	check := flags.Bool("check", false, "don't write bindings, fail if existing bindings drift from the artifacts")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	files, err := generateBindings(*artifactsDir, *pkg)
+	if err != nil {
+		return err
+	}
+	if *check {
+		missing, drifted, err := checkBindings(*outputDir, files)
+		if err != nil {
+			return err
+		}
+		if len(missing) > 0 {
+			return fmt.Errorf("bindings are not generated (%s), run \"make bindings\" and commit them", strings.Join(missing, ", "))
+		}
+		if len(drifted) > 0 {
+			return fmt.Errorf("bindings are out of date (%s), run \"make bindings\"", strings.Join(drifted, ", "))
+		}
+		return nil
+	}
+	if err := os.MkdirAll(*outputDir, 0755); err != nil {
+		return err
+	}
+	for name, code := range files {
+		if err := os.WriteFile(filepath.Join(*outputDir, name), code, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
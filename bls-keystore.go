@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/google/uuid"
+	"github.com/prysmaticlabs/prysm/v5/crypto/bls"
+	keystorev4 "github.com/wealdtech/go-eth2-wallet-encryptor-keystorev4"
+)
+
+// blsKeystore is an EIP-2335 keystore, the same format is imported by "geth bls account import"
+type blsKeystore struct {
+	Crypto      map[string]interface{} `json:"crypto"`
+	UUID        string                 `json:"uuid"`
+	Pubkey      string                 `json:"pubkey"`
+	Version     uint                   `json:"version"`
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Path        string                 `json:"path"`
+}
+
+// newBlsKeystore generates a new BLS key and encrypts it with the password
+func newBlsKeystore(password string, description string) (*blsKeystore, hexutil.Bytes, error) {
+	secretKey, err := bls.RandKey()
+	if err != nil {
+		return nil, nil, err
+	}
+	encryptor := keystorev4.New()
+	cryptoFields, err := encryptor.Encrypt(secretKey.Marshal(), password)
+	if err != nil {
+		return nil, nil, err
+	}
+	publicKey := secretKey.PublicKey().Marshal()
+	return &blsKeystore{
+		Crypto:      cryptoFields,
+		UUID:        uuid.NewString(),
+		Pubkey:      hex.EncodeToString(publicKey),
+		Version:     encryptor.Version(),
+		Name:        encryptor.Name(),
+		Description: description,
+	}, publicKey, nil
+}
+
+func runBlsKeygenCommand(args []string) error {
+	flags := flag.NewFlagSet("bls-keygen", flag.ContinueOnError)
+	outputDir := flags.String("output", "keystore/bls", "output directory for keystore files")
+	passwordFile := flags.String("password", "", "file with keystore password")
+	count := flags.Int("count", 0, "number of keys to generate if validators are not specified")
+	var validatorFlags stringListFlag
+	flags.Var(&validatorFlags, "validator", "validator to generate vote address for (can be repeated)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *passwordFile == "" {
+		return fmt.Errorf("password file is required")
+	}
+	password, err := readPasswordFile(*passwordFile)
+	if err != nil {
+		return err
+	}
+	validators, err := parseAddressList(validatorFlags)
+	if err != nil {
+		return err
+	}
+	if len(validators) == 0 && *count <= 0 {
+		return fmt.Errorf("specify validators or number of keys to generate")
+	}
+	if err := os.MkdirAll(*outputDir, 0700); err != nil {
+		return err
+	}
+	voteAddresses := make(map[common.Address]hexutil.Bytes)
+	generate := func(description string) (hexutil.Bytes, error) {
+		keystore, publicKey, err := newBlsKeystore(password, description)
+		if err != nil {
+			return nil, err
+		}
+		result, _ := json.MarshalIndent(keystore, "", "  ")
+		fileName := filepath.Join(*outputDir, fmt.Sprintf("keystore-%s-%s.json", description, keystore.Pubkey[:8]))
+		if err := os.WriteFile(fileName, result, 0600); err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(os.Stderr, " + %s: %s\n", fileName, publicKey)
+		return publicKey, nil
+	}
+	for _, validator := range validators {
+		publicKey, err := generate(validator.Hex())
+		if err != nil {
+			return err
+		}
+		voteAddresses[validator] = publicKey
+	}
+	for i := 0; len(validators) == 0 && i < *count; i++ {
+		if _, err := generate(fmt.Sprintf("%d", i)); err != nil {
+			return err
+		}
+	}
+	if len(voteAddresses) > 0 {
+		// print config snippet to be pasted into the network config
+		result, _ := json.MarshalIndent(map[string]interface{}{"voteAddresses": voteAddresses}, "", "  ")
+		os.Stdout.Write(append(result, '\n'))
+	}
+	return nil
+}
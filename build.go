@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"runtime"
+)
+
+func runBuildCommand(args []string) error {
+	flags := flag.NewFlagSet("build", flag.ContinueOnError)
+	configFile := flags.String("config", "", "network config file")
+	outputFile := flags.String("output", "stdout", "output genesis file")
+	reportFile := flags.String("report", "", "write machine-readable build report (JSON) to the file")
+	ctorGasLimit := flags.Uint64("ctor-gas-limit", 0, "gas limit for system contract's constructor and init (overrides config)")
+	tracer := flags.String("trace", "", "trace system contract's constructor and init: callTracer, prestateTracer, diffTracer or structLogger")
+	compact := flags.Bool("compact", false, "write compact JSON instead of indented")
+	allocFile := flags.String("alloc-output", "", "write genesis alloc into a separate file")
+	stripAlloc := flags.Bool("strip-alloc", false, "omit alloc from the genesis file (use with -alloc-output)")
+	headerFile := flags.String("header-output", "", "write hex encoded RLP of the genesis block header")
+	chainspecFile := flags.String("chainspec-output", "", "write chainspec (chain id, forks, system contracts and genesis hash)")
+	traceDir := flags.String("trace-dir", defaultTraceDir, "output directory for trace files, every chain gets a subdirectory named by its chain ID")
+	sharedState := flags.Bool("shared-state", false, "simulate all system contracts in one state and report differences from isolated simulation (overrides config)")
+	workers := flags.Int("workers", runtime.NumCPU(), "number of system contracts simulated concurrently")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	buildSlots = newBuildSlots(*workers)
+	if *configFile == "" {
+		return fmt.Errorf("config file is required")
+	}
+	config, err := readGenesisConfigFile(*configFile)
+	if err != nil {
+		return err
+	}
+	if *ctorGasLimit > 0 {
+		config.CtorGasLimit = *ctorGasLimit
+	}
+	if *sharedState {
+		config.SharedState = true
+	}
+	if *tracer != "" {
+		config.Trace = &ctorTraceConfig{Tracer: *tracer, Dir: *traceDir}
+	}
+	genesis, report, err := buildGenesis(*config, *outputFile, false, buildLog(*outputFile == "stdout"))
+	if err != nil {
+		return err
+	}
+	err = writeGenesisOutputs(genesis, genesisOutputs{
+		Genesis:    *outputFile,
+		Compact:    *compact,
+		StripAlloc: *stripAlloc,
+		Alloc:      *allocFile,
+		Header:     *headerFile,
+		Chainspec:  *chainspecFile,
+	})
+	if err != nil {
+		return err
+	}
+	if *reportFile != "" {
+		result, _ := json.MarshalIndent(report, "", "  ")
+		return writeOutputFile(*reportFile, append(result, '\n'))
+	}
+	return nil
+}
@@ -0,0 +1,432 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/asm"
+	"github.com/ethereum/go-ethereum/core/vm"
+)
+
+const (
+	functionAdded     = "added"
+	functionRemoved   = "removed"
+	functionChanged   = "changed"
+	functionUnchanged = "unchanged"
+)
+
+type instruction struct {
+	PC  uint64
+	Op  vm.OpCode
+	Arg []byte
+}
+
+func (i instruction) String() string {
+	if len(i.Arg) > 0 {
+		return fmt.Sprintf("%05x: %s %s", i.PC, i.Op, hexutil.Encode(i.Arg))
+	}
+	return fmt.Sprintf("%05x: %s", i.PC, i.Op)
+}
+
+// bytecodeSource is a runtime code with the contract name used to resolve function selectors
+type bytecodeSource struct {
+	Code     []byte
+	Contract string
+}
+
+// disassemble decodes the code till the end or the first malformed instruction (e.g. data appended to the code)
+func disassemble(code []byte) []instruction {
+	var result []instruction
+	it := asm.NewInstructionIterator(code)
+	for it.Next() {
+		result = append(result, instruction{PC: it.PC(), Op: it.Op(), Arg: it.Arg()})
+	}
+	return result
+}
+
+func isPush(op vm.OpCode) bool {
+	return op >= vm.PUSH1 && op <= vm.PUSH32
+}
+
+// dispatchTable finds entries of external functions in solidity selector dispatcher,
+// it matches "PUSH4 selector, [DUP], EQ, PUSH dest, JUMPI" sequences
+func dispatchTable(instructions []instruction) map[[4]byte]uint64 {
+	table := make(map[[4]byte]uint64)
+	for i, ins := range instructions {
+		if ins.Op != vm.PUSH4 || len(ins.Arg) != 4 {
+			continue
+		}
+		for j := i + 1; j <= i+2 && j+2 < len(instructions); j++ {
+			if instructions[j].Op != vm.EQ {
+				continue
+			}
+			if !isPush(instructions[j+1].Op) || instructions[j+2].Op != vm.JUMPI {
+				break
+			}
+			var selector [4]byte
+			copy(selector[:], ins.Arg)
+			if _, ok := table[selector]; !ok {
+				table[selector] = new(big.Int).SetBytes(instructions[j+1].Arg).Uint64()
+			}
+			break
+		}
+	}
+	return table
+}
+
+// normalizeInstructions replaces jump destinations with placeholders, so code shifts don't look like changes
+func normalizeInstructions(instructions []instruction, jumpdests map[uint64]bool) []string {
+	result := make([]string, len(instructions))
+	for i, ins := range instructions {
+		switch {
+		case isPush(ins.Op) && len(ins.Arg) <= 4 && jumpdests[new(big.Int).SetBytes(ins.Arg).Uint64()]:
+			result[i] = ins.Op.String() + " <jumpdest>"
+		case len(ins.Arg) > 0:
+			result[i] = ins.Op.String() + " " + hexutil.Encode(ins.Arg)
+		default:
+			result[i] = ins.Op.String()
+		}
+	}
+	return result
+}
+
+// disassembledCode is a runtime code split into external functions by the dispatch table,
+// code before the first function entry (dispatcher and shared code) is kept separately
+type disassembledCode struct {
+	Functions map[[4]byte][]instruction
+	Shared    []instruction
+	jumpdests map[uint64]bool
+}
+
+func splitFunctions(code []byte) *disassembledCode {
+	instructions := disassemble(stripCodeMetadata(code))
+	result := &disassembledCode{Functions: make(map[[4]byte][]instruction), jumpdests: make(map[uint64]bool)}
+	for _, ins := range instructions {
+		if ins.Op == vm.JUMPDEST {
+			result.jumpdests[ins.PC] = true
+		}
+	}
+	table := dispatchTable(instructions)
+	var entries []uint64
+	for _, dest := range table {
+		entries = append(entries, dest)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i] < entries[j] })
+	// function body lasts till the entry of the next function (internal functions placed in between are included)
+	bodyEnd := func(start uint64) uint64 {
+		for _, e := range entries {
+			if e > start {
+				return e
+			}
+		}
+		return ^uint64(0)
+	}
+	for selector, dest := range table {
+		end := bodyEnd(dest)
+		for _, ins := range instructions {
+			if ins.PC >= dest && ins.PC < end {
+				result.Functions[selector] = append(result.Functions[selector], ins)
+			}
+		}
+	}
+	for _, ins := range instructions {
+		if len(entries) == 0 || ins.PC < entries[0] {
+			result.Shared = append(result.Shared, ins)
+		}
+	}
+	return result
+}
+
+type functionDiff struct {
+	Selector hexutil.Bytes `json:"selector"`
+	Name     string        `json:"name,omitempty"`
+	Status   string        `json:"status"`
+	// Old and New are disassembled function bodies, filled only if disassembly is requested
+	Old []string `json:"old,omitempty"`
+	New []string `json:"new,omitempty"`
+}
+
+type bytecodeDiff struct {
+	OldSize     int         `json:"oldSize"`
+	NewSize     int         `json:"newSize"`
+	OldCodeHash common.Hash `json:"oldCodeHash"`
+	NewCodeHash common.Hash `json:"newCodeHash"`
+	OldRelease  string      `json:"oldRelease,omitempty"`
+	NewRelease  string      `json:"newRelease,omitempty"`
+	// Semantic is true if dispatcher or shared code is changed or a function is added, removed or changed,
+	// metadata and jump offsets are ignored
+	Semantic          bool           `json:"semantic"`
+	MetadataChanged   bool           `json:"metadataChanged"`
+	SharedCodeChanged bool           `json:"sharedCodeChanged"`
+	Functions         []functionDiff `json:"functions"`
+}
+
+// selectorNames resolves function signatures from ABI of the contract or of all system contracts if it's unknown
+func selectorNames(contract string) map[[4]byte]string {
+	names := make(map[[4]byte]string)
+	for _, c := range systemContracts {
+		if contract != "" && c.Name != contract {
+			continue
+		}
+		for _, method := range mustParseArtifactABI(c.RawArtifact).Methods {
+			var selector [4]byte
+			copy(selector[:], method.ID)
+			names[selector] = method.Sig
+		}
+	}
+	return names
+}
+
+func listing(instructions []instruction) []string {
+	result := make([]string, len(instructions))
+	for i, ins := range instructions {
+		result[i] = ins.String()
+	}
+	return result
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func diffBytecodes(oldSource, newSource bytecodeSource, withDisassembly bool) *bytecodeDiff {
+	result := &bytecodeDiff{
+		OldSize:     len(oldSource.Code),
+		NewSize:     len(newSource.Code),
+		OldCodeHash: runtimeCodeHash(oldSource.Code),
+		NewCodeHash: runtimeCodeHash(newSource.Code),
+		Functions:   []functionDiff{},
+	}
+	if oldSource.Contract != "" {
+		result.OldRelease = knownCodeReleases.release(oldSource.Contract, oldSource.Code)
+	}
+	if newSource.Contract != "" {
+		result.NewRelease = knownCodeReleases.release(newSource.Contract, newSource.Code)
+	}
+	result.MetadataChanged = !bytes.Equal(oldSource.Code, newSource.Code) && bytes.Equal(stripCodeMetadata(oldSource.Code), stripCodeMetadata(newSource.Code))
+	contract := newSource.Contract
+	if contract == "" {
+		contract = oldSource.Contract
+	}
+	names := selectorNames(contract)
+	oldCode, newCode := splitFunctions(oldSource.Code), splitFunctions(newSource.Code)
+	result.SharedCodeChanged = !equalStrings(normalizeInstructions(oldCode.Shared, oldCode.jumpdests), normalizeInstructions(newCode.Shared, newCode.jumpdests))
+	selectors := make(map[[4]byte]bool)
+	for selector := range oldCode.Functions {
+		selectors[selector] = true
+	}
+	for selector := range newCode.Functions {
+		selectors[selector] = true
+	}
+	for selector := range selectors {
+		oldBody, inOld := oldCode.Functions[selector]
+		newBody, inNew := newCode.Functions[selector]
+		diff := functionDiff{Selector: common.CopyBytes(selector[:]), Name: names[selector], Status: functionUnchanged}
+		switch {
+		case !inOld:
+			diff.Status = functionAdded
+		case !inNew:
+			diff.Status = functionRemoved
+		case !equalStrings(normalizeInstructions(oldBody, oldCode.jumpdests), normalizeInstructions(newBody, newCode.jumpdests)):
+			diff.Status = functionChanged
+		}
+		if withDisassembly && diff.Status != functionUnchanged {
+			diff.Old, diff.New = listing(oldBody), listing(newBody)
+		}
+		result.Functions = append(result.Functions, diff)
+		result.Semantic = result.Semantic || diff.Status != functionUnchanged
+	}
+	result.Semantic = result.Semantic || result.SharedCodeChanged
+	sort.Slice(result.Functions, func(i, j int) bool {
+		if result.Functions[i].Name != result.Functions[j].Name {
+			return result.Functions[i].Name < result.Functions[j].Name
+		}
+		return bytes.Compare(result.Functions[i].Selector, result.Functions[j].Selector) < 0
+	})
+	return result
+}
+
+// readBytecodeSource reads runtime code, supported sources are:
+//   - 0x... hex encoded code or a file with hex encoded code
+//   - artifact:<Contract> embedded artifact or artifact:<file.json> forge artifact
+//   - genesis:<file>:<Contract|address> account of the genesis file or state dump
+//   - proposal:<file>[:<Contract>] new code of the upgrade proposal (contract is optional if only one is upgraded)
+func readBytecodeSource(spec string) (bytecodeSource, error) {
+	kind, rest, _ := strings.Cut(spec, ":")
+	switch kind {
+	case "artifact":
+		if c, ok := systemContractByName(rest); ok {
+			artifact, err := parseArtifact(c.RawArtifact)
+			if err != nil {
+				return bytecodeSource{}, err
+			}
+			code, err := hexutil.Decode(artifact.DeployedBytecode)
+			return bytecodeSource{Code: code, Contract: c.Name}, err
+		}
+		rawArtifact, err := os.ReadFile(rest)
+		if err != nil {
+			return bytecodeSource{}, err
+		}
+		artifact, err := parseArtifact(rawArtifact)
+		if err != nil {
+			return bytecodeSource{}, fmt.Errorf("failed to parse artifact (%s): %w", rest, err)
+		}
+		code, err := hexutil.Decode(artifact.DeployedBytecode)
+		return bytecodeSource{Code: code}, err
+	case "genesis":
+		filePath, account, ok := strings.Cut(rest, ":")
+		if !ok {
+			return bytecodeSource{}, fmt.Errorf("contract is required (%s), use genesis:<file>:<Contract|address>", spec)
+		}
+		source, err := readStateSource(filePath)
+		if err != nil {
+			return bytecodeSource{}, err
+		}
+		result := bytecodeSource{}
+		address := common.HexToAddress(account)
+		if c, ok := systemContractByName(account); ok {
+			address, result.Contract = c.Address, c.Name
+		} else if c, ok := systemContractByAddress(address); ok {
+			result.Contract = c.Name
+		}
+		if result.Code = source.Alloc[address].Code; len(result.Code) == 0 {
+			return bytecodeSource{}, fmt.Errorf("there is no code at %s in %s", address.Hex(), filePath)
+		}
+		return result, nil
+	case "proposal":
+		filePath, contract, _ := strings.Cut(rest, ":")
+		return readProposalBytecode(filePath, contract)
+	}
+	if !strings.HasPrefix(spec, "0x") {
+		fileContents, err := os.ReadFile(spec)
+		if err != nil {
+			return bytecodeSource{}, err
+		}
+		spec = strings.TrimSpace(string(fileContents))
+	}
+	code, err := hexutil.Decode(spec)
+	return bytecodeSource{Code: code}, err
+}
+
+// readProposalBytecode extracts new code from upgradeSystemSmartContract calls of the proposal
+func readProposalBytecode(filePath string, contract string) (bytecodeSource, error) {
+	proposal, err := readGovernanceProposal(filePath)
+	if err != nil {
+		return bytecodeSource{}, err
+	}
+	method := mustParseArtifactABI(runtimeUpgradeRawArtifact).Methods["upgradeSystemSmartContract"]
+	var found []bytecodeSource
+	for i, target := range proposal.Targets {
+		calldata := proposal.Calldatas[i]
+		if target != runtimeUpgradeAddress || len(calldata) < 4 || !bytes.Equal(calldata[:4], method.ID) {
+			continue
+		}
+		args, err := method.Inputs.Unpack(calldata[4:])
+		if err != nil {
+			return bytecodeSource{}, fmt.Errorf("failed to decode upgrade call #%d: %w", i, err)
+		}
+		address, code := args[0].(common.Address), args[1].([]byte)
+		c, _ := systemContractByAddress(address)
+		if contract != "" && !strings.EqualFold(contract, c.Name) && common.HexToAddress(contract) != address {
+			continue
+		}
+		found = append(found, bytecodeSource{Code: code, Contract: c.Name})
+	}
+	switch {
+	case len(found) == 0:
+		return bytecodeSource{}, fmt.Errorf("proposal (%s) doesn't upgrade %s", filePath, contract)
+	case len(found) > 1:
+		return bytecodeSource{}, fmt.Errorf("proposal (%s) upgrades several contracts, use proposal:<file>:<Contract>", filePath)
+	}
+	return found[0], nil
+}
+
+func printBytecodeDiff(diff *bytecodeDiff, showUnchanged bool) {
+	fmt.Printf("old: %d bytes, code hash %s %s\n", diff.OldSize, diff.OldCodeHash.Hex(), diff.OldRelease)
+	fmt.Printf("new: %d bytes, code hash %s %s\n", diff.NewSize, diff.NewCodeHash.Hex(), diff.NewRelease)
+	switch {
+	case diff.MetadataChanged:
+		fmt.Printf(" ~ only metadata is changed\n")
+	case diff.OldCodeHash == diff.NewCodeHash:
+		fmt.Printf(" ~ codes are identical\n")
+	case !diff.Semantic:
+		fmt.Printf(" ~ only jump offsets are changed, functions and shared code are the same\n")
+	}
+	if diff.SharedCodeChanged {
+		fmt.Printf(" ~ dispatcher or shared code is changed\n")
+	}
+	marks := map[string]string{functionAdded: "+", functionRemoved: "-", functionChanged: "~", functionUnchanged: " "}
+	for _, f := range diff.Functions {
+		if f.Status == functionUnchanged && !showUnchanged {
+			continue
+		}
+		fmt.Printf(" %s %-10s %s %s\n", marks[f.Status], f.Status, f.Selector, f.Name)
+		if len(f.Old) > 0 {
+			fmt.Printf("    old:\n      %s\n", strings.Join(f.Old, "\n      "))
+		}
+		if len(f.New) > 0 {
+			fmt.Printf("    new:\n      %s\n", strings.Join(f.New, "\n      "))
+		}
+	}
+}
+
+func runBytecodeDiffCommand(args []string) error {
+	flags := flag.NewFlagSet("bytecode-diff", flag.ContinueOnError)
+	oldSpec := flags.String("old", "", "old code: 0x..., file, artifact:<Contract|file>, genesis:<file>:<Contract|address> or proposal:<file>[:<Contract>]")
+	newSpec := flags.String("new", "", "new code (same formats as -old)")
+	contract := flags.String("contract", "", "system contract whose ABI names function selectors (detected from sources if possible)")
+	withDisassembly := flags.Bool("disasm", false, "show disassembly of added, removed and changed functions")
+	showUnchanged := flags.Bool("all", false, "show unchanged functions too")
+	format := flags.String("format", "text", "output format: text or json")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *oldSpec == "" || *newSpec == "" {
+		return fmt.Errorf("old and new codes are required")
+	}
+	oldSource, err := readBytecodeSource(*oldSpec)
+	if err != nil {
+		return err
+	}
+	newSource, err := readBytecodeSource(*newSpec)
+	if err != nil {
+		return err
+	}
+	if *contract != "" {
+		c, ok := systemContractByName(*contract)
+		if !ok {
+			return fmt.Errorf("unknown system contract (%s)", *contract)
+		}
+		oldSource.Contract, newSource.Contract = c.Name, c.Name
+	}
+	diff := diffBytecodes(oldSource, newSource, *withDisassembly)
+	switch *format {
+	case "json":
+		result, _ := json.MarshalIndent(diff, "", "  ")
+		os.Stdout.Write(append(result, '\n'))
+	case "text":
+		printBytecodeDiff(diff, *showUnchanged)
+	default:
+		return fmt.Errorf("unknown output format (%s)", *format)
+	}
+	if diff.Semantic {
+		return fmt.Errorf("codes differ semantically")
+	}
+	return nil
+}
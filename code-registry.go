@@ -0,0 +1,115 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const codeRegistryFile = "code-registry.json"
+
+// unknownRelease labels code that isn't found in the registry
+const unknownRelease = "unknown"
+
+//go:embed code-registry.json
+var codeRegistryJson []byte
+
+// codeRelease is a known-good release of the system contract
+type codeRelease struct {
+	Contract string `json:"contract"`
+	Release  string `json:"release"`
+	// Commit is empty for releases recovered from genesis files of launched networks
+	Commit string `json:"commit,omitempty"`
+}
+
+// codeRegistry maps runtime code hashes (w/o solc metadata) to releases
+type codeRegistry map[common.Hash]codeRelease
+
+var knownCodeReleases = mustParseCodeRegistry(codeRegistryJson)
+
+func mustParseCodeRegistry(data []byte) codeRegistry {
+	registry := make(codeRegistry)
+	if err := json.Unmarshal(data, &registry); err != nil {
+		panic(err)
+	}
+	return registry
+}
+
+// stripCodeMetadata removes CBOR encoded solc metadata from the end of the runtime code,
+// the last two bytes of the code contain length of the metadata
+func stripCodeMetadata(code []byte) []byte {
+	if len(code) < 2 {
+		return code
+	}
+	length := int(code[len(code)-2])<<8 | int(code[len(code)-1])
+	if length+2 > len(code) {
+		return code
+	}
+	// metadata is a CBOR map with up to 5 keys (ipfs, bzzr0, bzzr1, experimental and solc)
+	if start := code[len(code)-length-2]; (start < 0xa1 || start > 0xa5) && start != 0xbf {
+		return code
+	}
+	return code[:len(code)-length-2]
+}
+
+// runtimeCodeHash is a hash of the runtime code w/o metadata, so it doesn't depend on source paths and comments
+func runtimeCodeHash(code []byte) common.Hash {
+	return crypto.Keccak256Hash(stripCodeMetadata(code))
+}
+
+// release returns release name of the contract's code or "unknown"
+func (r codeRegistry) release(contract string, code []byte) string {
+	release, ok := r[runtimeCodeHash(code)]
+	if !ok || release.Contract != contract {
+		return unknownRelease
+	}
+	return release.Release
+}
+
+func runRegisterReleaseCommand(args []string) error {
+	flags := flag.NewFlagSet("register-release", flag.ContinueOnError)
+	release := flags.String("release", "", "release name, e.g. v1.4.0")
+	commit := flags.String("commit", "", "source commit of the artifacts")
+	artifactsDir := flags.String("artifacts", "", "forge output directory (embedded artifacts are used by default)")
+	registryFile := flags.String("registry", codeRegistryFile, "registry file")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *release == "" || *commit == "" {
+		return fmt.Errorf("release name and commit are required")
+	}
+	data, err := os.ReadFile(*registryFile)
+	if err != nil {
+		return err
+	}
+	registry := mustParseCodeRegistry(data)
+	for _, c := range systemContracts {
+		rawArtifact, err := readSystemContractArtifact(*artifactsDir, c)
+		if err != nil {
+			return err
+		}
+		artifact, err := parseArtifact(rawArtifact)
+		if err != nil {
+			return fmt.Errorf("failed to parse artifact of %s: %w", c.Name, err)
+		}
+		code, err := hexutil.Decode(artifact.DeployedBytecode)
+		if err != nil {
+			return fmt.Errorf("bad deployed bytecode of %s: %w", c.Name, err)
+		}
+		hash := runtimeCodeHash(code)
+		if existing, ok := registry[hash]; ok {
+			fmt.Printf(" ~ %s code is registered already as %s %s (%s)\n", c.Name, existing.Contract, existing.Release, hash.Hex())
+			continue
+		}
+		registry[hash] = codeRelease{Contract: c.Name, Release: *release, Commit: *commit}
+		fmt.Printf(" + %s %s (%s)\n", c.Name, *release, hash.Hex())
+	}
+	result, _ := json.MarshalIndent(registry, "", "  ")
+	return writeOutputFile(*registryFile, append(result, '\n'))
+}
@@ -0,0 +1,393 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// configExtendsKey lists base configs of the overlay, bases are merged in order and the overlay is merged on top
+const configExtendsKey = "extends"
+
+// configSchemaKey points editors to the JSON Schema of the config, it's ignored by the tool
+const configSchemaKey = "$schema"
+
+// configReplaceSuffix makes the overlay replace the value instead of merging it, e.g. "validators!": [...]
+const configReplaceSuffix = "!"
+
+// rawEnvMarker marks unquoted ${VAR} references, their values are decoded as JSON (numbers, booleans, objects)
+const rawEnvMarker = "\x00"
+
+var envReferenceRegexp = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(?::-([^}]*))?\}`)
+
+// envValue is a value substituted from the environment, it's unwrapped when merged into the resolved config
+type envValue struct {
+	Value     interface{}
+	Reference string
+}
+
+// resolvedConfig is a config with all overlays and environment variables applied, every leaf value has its source
+type resolvedConfig struct {
+	Config  map[string]interface{} `json:"config"`
+	Sources map[string]string      `json:"sources"`
+}
+
+func lookupEnvReference(name string, defaultValue string, hasDefault bool) (string, error) {
+	if value, ok := os.LookupEnv(name); ok {
+		return value, nil
+	}
+	if hasDefault {
+		return defaultValue, nil
+	}
+	return "", fmt.Errorf("environment variable (%s) is not set", name)
+}
+
+// quoteRawEnvReferences turns unquoted ${VAR} references into marked strings, so the document can be parsed as JSON
+func quoteRawEnvReferences(data []byte) []byte {
+	var result bytes.Buffer
+	inString, escaped := false, false
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch {
+		case inString:
+			if escaped {
+				escaped = false
+			} else if c == '\\' {
+				escaped = true
+			} else if c == '"' {
+				inString = false
+			}
+		case c == '"':
+			inString = true
+		case c == '$':
+			if loc := envReferenceRegexp.FindIndex(data[i:]); loc != nil && loc[0] == 0 {
+				marked, _ := json.Marshal(rawEnvMarker + string(data[i+2:i+loc[1]-1]))
+				result.Write(marked)
+				i += loc[1] - 1
+				continue
+			}
+		}
+		result.WriteByte(c)
+	}
+	return result.Bytes()
+}
+
+// substituteEnvString replaces all ${VAR} and ${VAR:-default} references of the string
+func substituteEnvString(s string) (string, []string, error) {
+	var refs []string
+	var err error
+	result := envReferenceRegexp.ReplaceAllStringFunc(s, func(ref string) string {
+		match := envReferenceRegexp.FindStringSubmatch(ref)
+		value, e := lookupEnvReference(match[1], match[2], strings.Contains(ref, ":-"))
+		if e != nil && err == nil {
+			err = e
+		}
+		refs = append(refs, ref)
+		return value
+	})
+	return result, refs, err
+}
+
+func substituteEnv(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		if strings.HasPrefix(v, rawEnvMarker) {
+			ref := strings.TrimPrefix(v, rawEnvMarker)
+			name, defaultValue, hasDefault := strings.Cut(ref, ":-")
+			raw, err := lookupEnvReference(name, defaultValue, hasDefault)
+			if err != nil {
+				return nil, err
+			}
+			var decoded interface{}
+			decoder := json.NewDecoder(strings.NewReader(raw))
+			decoder.UseNumber()
+			if err := decoder.Decode(&decoded); err != nil {
+				return nil, fmt.Errorf("environment variable (%s) is not a JSON value: %w", name, err)
+			}
+			return envValue{Value: decoded, Reference: "${" + ref + "}"}, nil
+		}
+		result, refs, err := substituteEnvString(v)
+		if err != nil || len(refs) == 0 {
+			return result, err
+		}
+		return envValue{Value: result, Reference: strings.Join(refs, ", ")}, nil
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			key, _, err := substituteEnvString(key)
+			if err != nil {
+				return nil, err
+			}
+			if result[key], err = substituteEnv(item); err != nil {
+				return nil, err
+			}
+		}
+		return result, nil
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			var err error
+			if result[i], err = substituteEnv(item); err != nil {
+				return nil, err
+			}
+		}
+		return result, nil
+	}
+	return value, nil
+}
+
+func configPath(prefix string, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+// forgetSources removes sources of the value and all nested values
+func forgetSources(sources map[string]string, path string) {
+	for p := range sources {
+		if p == path || strings.HasPrefix(p, path+".") || strings.HasPrefix(p, path+"[") {
+			delete(sources, p)
+		}
+	}
+}
+
+// adoptConfigValue unwraps environment values and records sources of all leaves
+func adoptConfigValue(value interface{}, path string, source string, sources map[string]string) interface{} {
+	switch v := value.(type) {
+	case envValue:
+		sources[path] = fmt.Sprintf("%s via %s", source, v.Reference)
+		return v.Value
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			result[key] = adoptConfigValue(item, configPath(path, key), source, sources)
+		}
+		if len(v) == 0 {
+			sources[path] = source
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = adoptConfigValue(item, fmt.Sprintf("%s[%d]", path, i), source, sources)
+		}
+		if len(v) == 0 {
+			sources[path] = source
+		}
+		return result
+	}
+	sources[path] = source
+	return value
+}
+
+// mergeConfig deep merges overlay into the config: objects are merged by keys, new items are appended to arrays,
+// null removes the value and a key with "!" suffix replaces the value
+func mergeConfig(config map[string]interface{}, overlay map[string]interface{}, prefix string, source string, sources map[string]string) {
+	keys := make([]string, 0, len(overlay))
+	for key := range overlay {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value := overlay[key]
+		name := strings.TrimSuffix(key, configReplaceSuffix)
+		replace := name != key
+		path := configPath(prefix, name)
+		if value == nil {
+			delete(config, name)
+			forgetSources(sources, path)
+			continue
+		}
+		existing, ok := config[name]
+		if !replace && ok {
+			if existingMap, ok := existing.(map[string]interface{}); ok {
+				if overlayMap, ok := value.(map[string]interface{}); ok {
+					mergeConfig(existingMap, overlayMap, path, source, sources)
+					continue
+				}
+			}
+			if existingSlice, ok := existing.([]interface{}); ok {
+				if overlaySlice, ok := value.([]interface{}); ok {
+					for _, item := range overlaySlice {
+						item = adoptConfigValue(item, fmt.Sprintf("%s[%d]", path, len(existingSlice)), source, sources)
+						if !containsConfigValue(existingSlice, item) {
+							existingSlice = append(existingSlice, item)
+						} else {
+							forgetSources(sources, fmt.Sprintf("%s[%d]", path, len(existingSlice)))
+						}
+					}
+					config[name] = existingSlice
+					continue
+				}
+			}
+		}
+		forgetSources(sources, path)
+		config[name] = adoptConfigValue(value, path, source, sources)
+	}
+}
+
+func containsConfigValue(values []interface{}, value interface{}) bool {
+	for _, v := range values {
+		if reflect.DeepEqual(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// configReader reads config documents, configs of built-in networks are read from the embedded files
+type configReader func(configFile string) ([]byte, error)
+
+// readConfigLayer reads single config document with environment variables substituted
+func readConfigLayer(read configReader, configFile string) (map[string]interface{}, []string, error) {
+	fileContents, err := read(configFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	var document map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(quoteRawEnvReferences(fileContents)))
+	decoder.UseNumber()
+	if err := decoder.Decode(&document); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse config (%s): %w", configFile, err)
+	}
+	var bases []string
+	switch extends := document[configExtendsKey].(type) {
+	case nil:
+	case string:
+		bases = []string{extends}
+	case []interface{}:
+		for _, base := range extends {
+			s, ok := base.(string)
+			if !ok {
+				return nil, nil, fmt.Errorf("bad %s value in config (%s)", configExtendsKey, configFile)
+			}
+			bases = append(bases, s)
+		}
+	default:
+		return nil, nil, fmt.Errorf("bad %s value in config (%s)", configExtendsKey, configFile)
+	}
+	delete(document, configExtendsKey)
+	delete(document, configSchemaKey)
+	for i, base := range bases {
+		if base, _, err = substituteEnvString(base); err != nil {
+			return nil, nil, err
+		}
+		if !filepath.IsAbs(base) {
+			base = filepath.Join(filepath.Dir(configFile), base)
+		}
+		bases[i] = base
+	}
+	substituted, err := substituteEnv(document)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to substitute environment variables of config (%s): %w", configFile, err)
+	}
+	return substituted.(map[string]interface{}), bases, nil
+}
+
+func resolveConfigLayers(read configReader, configFile string, result *resolvedConfig, visiting map[string]bool) error {
+	if visiting[configFile] {
+		return fmt.Errorf("config (%s) extends itself", configFile)
+	}
+	visiting[configFile] = true
+	defer delete(visiting, configFile)
+	document, bases, err := readConfigLayer(read, configFile)
+	if err != nil {
+		return err
+	}
+	for _, base := range bases {
+		if err := resolveConfigLayers(read, base, result, visiting); err != nil {
+			return err
+		}
+	}
+	mergeConfig(result.Config, document, "", configFile, result.Sources)
+	return nil
+}
+
+// resolveConfigFile reads the config with all its base configs
+func resolveConfigFile(configFile string) (*resolvedConfig, error) {
+	return resolveConfig(os.ReadFile, configFile)
+}
+
+func resolveConfig(read configReader, configFile string) (*resolvedConfig, error) {
+	result := &resolvedConfig{Config: make(map[string]interface{}), Sources: make(map[string]string)}
+	if err := resolveConfigLayers(read, filepath.Clean(configFile), result, make(map[string]bool)); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func printResolvedConfig(config *resolvedConfig) {
+	paths := make([]string, 0, len(config.Sources))
+	for path := range config.Sources {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		value := lookupConfigPath(config.Config, path)
+		encoded, _ := json.Marshal(value)
+		fmt.Printf("%s = %s (%s)\n", path, encoded, config.Sources[path])
+	}
+}
+
+// lookupConfigPath returns value of the resolved config by path, paths are produced by adoptConfigValue
+func lookupConfigPath(value interface{}, path string) interface{} {
+	for path != "" {
+		var key string
+		if strings.HasPrefix(path, "[") {
+			var index int
+			end := strings.Index(path, "]")
+			fmt.Sscanf(path[1:end], "%d", &index)
+			value, path = value.([]interface{})[index], strings.TrimPrefix(path[end+1:], ".")
+			continue
+		}
+		end := strings.IndexAny(path, ".[")
+		if end < 0 {
+			key, path = path, ""
+		} else {
+			key, path = path[:end], strings.TrimPrefix(path[end:], ".")
+		}
+		value = value.(map[string]interface{})[key]
+	}
+	return value
+}
+
+func runResolveConfigCommand(args []string) error {
+	flags := flag.NewFlagSet("resolve-config", flag.ContinueOnError)
+	configFile := flags.String("config", "", "config file, base configs are listed in its \"extends\" field")
+	format := flags.String("format", "text", "output format: text (values with sources), json or config (resolved config only, it can be validated against the schema)")
+	outputFile := flags.String("output", "stdout", "output file of json and config formats")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *configFile == "" {
+		return fmt.Errorf("config file is required")
+	}
+	resolved, err := resolveConfigFile(*configFile)
+	if err != nil {
+		return err
+	}
+	// make sure resolved config is a valid genesis config
+	if _, err := readGenesisConfigFile(*configFile); err != nil {
+		return err
+	}
+	switch *format {
+	case "json":
+		result, _ := json.MarshalIndent(resolved, "", "  ")
+		return writeOutputFile(*outputFile, append(result, '\n'))
+	case "config":
+		result, _ := json.MarshalIndent(resolved.Config, "", "  ")
+		return writeOutputFile(*outputFile, append(result, '\n'))
+	case "text":
+		printResolvedConfig(resolved)
+		return nil
+	}
+	return fmt.Errorf("unknown output format (%s)", *format)
+}
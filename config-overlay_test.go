@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func decodeConfigDocument(t *testing.T, document string) map[string]interface{} {
+	var result map[string]interface{}
+	if err := json.Unmarshal([]byte(document), &result); err != nil {
+		t.Fatal(err)
+	}
+	return result
+}
+
+func TestMergeConfig(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		config   string
+		overlay  string
+		expected string
+		sources  map[string]string
+	}{
+		{
+			name:     "objects are merged by keys",
+			config:   `{"a": {"x": 1, "y": 2}}`,
+			overlay:  `{"a": {"y": 3, "z": 4}}`,
+			expected: `{"a": {"x": 1, "y": 3, "z": 4}}`,
+			sources:  map[string]string{"a.x": "base", "a.y": "overlay", "a.z": "overlay"},
+		},
+		{
+			name:     "new items are appended to arrays",
+			config:   `{"a": [1, 2]}`,
+			overlay:  `{"a": [2, 3]}`,
+			expected: `{"a": [1, 2, 3]}`,
+			sources:  map[string]string{"a[0]": "base", "a[1]": "base", "a[2]": "overlay"},
+		},
+		{
+			name:     "scalar is replaced",
+			config:   `{"a": 1, "b": "x"}`,
+			overlay:  `{"a": 2}`,
+			expected: `{"a": 2, "b": "x"}`,
+			sources:  map[string]string{"a": "overlay", "b": "base"},
+		},
+		{
+			name:     "null removes the value",
+			config:   `{"a": {"x": 1}, "b": [1], "c": 1}`,
+			overlay:  `{"a": null, "b": null}`,
+			expected: `{"c": 1}`,
+			sources:  map[string]string{"c": "base"},
+		},
+		{
+			name:     "null removes the nested value",
+			config:   `{"a": {"x": 1, "y": 2}}`,
+			overlay:  `{"a": {"x": null}}`,
+			expected: `{"a": {"y": 2}}`,
+			sources:  map[string]string{"a.y": "base"},
+		},
+		{
+			name:     "null of missing value is ignored",
+			config:   `{"a": 1}`,
+			overlay:  `{"b": null}`,
+			expected: `{"a": 1}`,
+			sources:  map[string]string{"a": "base"},
+		},
+		{
+			name:     "replace suffix replaces object",
+			config:   `{"a": {"x": 1, "y": 2}}`,
+			overlay:  `{"a!": {"z": 3}}`,
+			expected: `{"a": {"z": 3}}`,
+			sources:  map[string]string{"a.z": "overlay"},
+		},
+		{
+			name:     "replace suffix replaces array",
+			config:   `{"a": [1, 2]}`,
+			overlay:  `{"a!": [3]}`,
+			expected: `{"a": [3]}`,
+			sources:  map[string]string{"a[0]": "overlay"},
+		},
+		{
+			name:     "replace suffix sets missing value",
+			config:   `{}`,
+			overlay:  `{"a!": []}`,
+			expected: `{"a": []}`,
+			sources:  map[string]string{"a": "overlay"},
+		},
+		{
+			name:     "null with replace suffix removes the value",
+			config:   `{"a": [1]}`,
+			overlay:  `{"a!": null}`,
+			expected: `{}`,
+			sources:  map[string]string{},
+		},
+		{
+			name:     "object replaces array",
+			config:   `{"a": [1]}`,
+			overlay:  `{"a": {"x": 1}}`,
+			expected: `{"a": {"x": 1}}`,
+			sources:  map[string]string{"a.x": "overlay"},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			config, sources := make(map[string]interface{}), make(map[string]string)
+			mergeConfig(config, decodeConfigDocument(t, test.config), "", "base", sources)
+			mergeConfig(config, decodeConfigDocument(t, test.overlay), "", "overlay", sources)
+			if expected := decodeConfigDocument(t, test.expected); !reflect.DeepEqual(config, expected) {
+				result, _ := json.Marshal(config)
+				t.Errorf("expected %s, got %s", test.expected, result)
+			}
+			if !reflect.DeepEqual(sources, test.sources) {
+				t.Errorf("expected sources %v, got %v", test.sources, sources)
+			}
+		})
+	}
+}
+
+func TestQuoteRawEnvReferences(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		data     string
+		expected string
+	}{
+		{"raw reference", `{"a": ${CHAIN_ID}}`, `{"a": "\u0000CHAIN_ID"}`},
+		{"raw reference with default", `{"a": ${CHAIN_ID:-1337}}`, `{"a": "\u0000CHAIN_ID:-1337"}`},
+		{"raw references in array", `[${A},${B}]`, `["\u0000A","\u0000B"]`},
+		{"quoted reference is kept", `{"a": "${A}"}`, `{"a": "${A}"}`},
+		{"reference in key is kept", `{"${A}": 1}`, `{"${A}": 1}`},
+		{"escaped quote doesn't end string", `{"a": "\"${A}"}`, `{"a": "\"${A}"}`},
+		{"escaped backslash ends string", `{"a": "\\", "b": ${B}}`, `{"a": "\\", "b": "\u0000B"}`},
+		{"dollar without braces is kept", `{"a": $A}`, `{"a": $A}`},
+		{"bad variable name is kept", `{"a": ${1A}}`, `{"a": ${1A}}`},
+		{"reference at the end", `{"a": ${A}`, `{"a": "\u0000A"`},
+		{"no references", `{"a": 1}`, `{"a": 1}`},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			if result := string(quoteRawEnvReferences([]byte(test.data))); result != test.expected {
+				t.Errorf("expected %s, got %s", test.expected, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+)
+
+const configSchemaFile = "genesis-config.schema.json"
+
+var (
+	addressType         = reflect.TypeOf(common.Address{})
+	hexOrDecimal256Type = reflect.TypeOf(math.HexOrDecimal256{})
+	hexBytesType        = reflect.TypeOf(hexutil.Bytes{})
+)
+
+// jsonFieldName returns json name of the struct field, fields w/o json name are skipped
+func jsonFieldName(field reflect.StructField) (string, bool) {
+	name := strings.Split(field.Tag.Get("json"), ",")[0]
+	if name == "-" || !field.IsExported() {
+		return "", false
+	}
+	if name == "" {
+		name = field.Name
+	}
+	return name, true
+}
+
+// checkConfigAddresses validates EIP-55 checksums of all mixed case addresses of the config document,
+// the document is walked along the type, so only address fields and keys are checked
+func checkConfigAddresses(t reflect.Type, value interface{}, path string) error {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if value == nil {
+		return nil
+	}
+	switch {
+	case t == addressType:
+		if s, ok := value.(string); ok {
+			if _, err := parseChecksumAddress(s); err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+		}
+	case t.Kind() == reflect.Struct:
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		for i := 0; i < t.NumField(); i++ {
+			if name, ok := jsonFieldName(t.Field(i)); ok {
+				if err := checkConfigAddresses(t.Field(i).Type, object[name], configPath(path, name)); err != nil {
+					return err
+				}
+			}
+		}
+	case t.Kind() == reflect.Map:
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		keys := make([]string, 0, len(object))
+		for key := range object {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if err := checkConfigAddresses(t.Key(), key, configPath(path, key)); err != nil {
+				return err
+			}
+			if err := checkConfigAddresses(t.Elem(), object[key], configPath(path, key)); err != nil {
+				return err
+			}
+		}
+	case t.Kind() == reflect.Slice && t != hexBytesType:
+		items, ok := value.([]interface{})
+		if !ok {
+			return nil
+		}
+		for i, item := range items {
+			if err := checkConfigAddresses(t.Elem(), item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// decodeGenesisConfig strictly decodes resolved config, unknown fields and bad address checksums are rejected
+func decodeGenesisConfig(document map[string]interface{}) (*genesisConfig, error) {
+	if err := checkConfigAddresses(reflect.TypeOf(genesisConfig{}), document, ""); err != nil {
+		return nil, err
+	}
+	fileContents, err := json.Marshal(document)
+	if err != nil {
+		return nil, err
+	}
+	config := &genesisConfig{}
+	decoder := json.NewDecoder(bytes.NewReader(fileContents))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// readFieldComments collects doc and line comments of struct fields from the sources, keys are "type.Field"
+func readFieldComments(sourceDir string) (map[string]string, error) {
+	packages, err := parser.ParseDir(token.NewFileSet(), sourceDir, func(info os.FileInfo) bool {
+		return !strings.HasSuffix(info.Name(), "_test.go")
+	}, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	comments := make(map[string]string)
+	for _, pkg := range packages {
+		for _, file := range pkg.Files {
+			ast.Inspect(file, func(node ast.Node) bool {
+				spec, ok := node.(*ast.TypeSpec)
+				if !ok {
+					return true
+				}
+				structType, ok := spec.Type.(*ast.StructType)
+				if !ok {
+					return true
+				}
+				for _, field := range structType.Fields.List {
+					text := strings.TrimSpace(field.Doc.Text() + " " + field.Comment.Text())
+					if text == "" {
+						continue
+					}
+					for _, name := range field.Names {
+						comments[spec.Name.Name+"."+name.Name] = strings.Join(strings.Fields(text), " ")
+					}
+				}
+				return true
+			})
+		}
+	}
+	return comments, nil
+}
+
+func jsonSchemaOf(t reflect.Type, comments map[string]string) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch {
+	case t == addressType:
+		return map[string]interface{}{"type": "string", "pattern": "^0x[0-9a-fA-F]{40}$"}
+	case t == hexOrDecimal256Type:
+		return map[string]interface{}{"type": []string{"string", "integer"}, "pattern": "^(0x[0-9a-fA-F]+|[0-9]+)$", "minimum": 0}
+	case t == hexBytesType:
+		return map[string]interface{}{"type": "string", "pattern": "^0x([0-9a-fA-F]{2})*$"}
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		properties := make(map[string]interface{})
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name, ok := jsonFieldName(field)
+			if !ok {
+				continue
+			}
+			schema := jsonSchemaOf(field.Type, comments)
+			if description, ok := comments[t.Name()+"."+field.Name]; ok {
+				schema["description"] = description
+			}
+			properties[name] = schema
+		}
+		return map[string]interface{}{"type": "object", "properties": properties, "additionalProperties": false}
+	case reflect.Map:
+		schema := map[string]interface{}{"type": "object", "additionalProperties": jsonSchemaOf(t.Elem(), comments)}
+		if t.Key() == addressType {
+			schema["propertyNames"] = jsonSchemaOf(t.Key(), comments)
+		}
+		return schema
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": jsonSchemaOf(t.Elem(), comments)}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer", "minimum": 0}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	}
+	return map[string]interface{}{}
+}
+
+// generateConfigSchema creates JSON Schema of the resolved network config, descriptions are taken from field comments,
+// overlay files (extends, null values, "!" keys and ${VAR} references) are not covered, only their resolved configs
+func generateConfigSchema(sourceDir string) ([]byte, error) {
+	comments, err := readFieldComments(sourceDir)
+	if err != nil {
+		return nil, err
+	}
+	schema := jsonSchemaOf(reflect.TypeOf(genesisConfig{}), comments)
+	schema["$schema"] = "http://json-schema.org/draft-07/schema#"
+	schema["title"] = "Chiliz genesis config"
+	properties := schema["properties"].(map[string]interface{})
+	properties[configSchemaKey] = map[string]interface{}{"type": "string"}
+	result, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(result, '\n'), nil
+}
+
+func runConfigSchemaCommand(args []string) error {
+	flags := flag.NewFlagSet("config-schema", flag.ContinueOnError)
+	sourceDir := flags.String("source", ".", "directory with sources of the config types")
+	outputFile := flags.String("output", configSchemaFile, "output file")
+	check := flags.Bool("check", false, "don't write schema, fail if existing schema drifts from the config types")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	schema, err := generateConfigSchema(*sourceDir)
+	if err != nil {
+		return err
+	}
+	if *check {
+		existing, err := os.ReadFile(*outputFile)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		if !bytes.Equal(existing, schema) {
+			return fmt.Errorf("config schema is out of date (%s), run \"make schema\"", *outputFile)
+		}
+		return nil
+	}
+	return writeOutputFile(*outputFile, schema)
+}
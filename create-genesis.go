@@ -1,27 +1,27 @@
 package main
 
 import (
-	_ "embed"
+	"bytes"
+	"embed"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"io/fs"
 	"io/ioutil"
 	"math/big"
 	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
-	"unicode"
 	"unsafe"
 
 	"time"
 
 	"github.com/ethereum/go-ethereum/common/systemcontract"
-	"github.com/ethereum/go-ethereum/triedb"
 
 	"github.com/ethereum/go-ethereum/common/math"
 
-	_ "github.com/ethereum/go-ethereum/eth/tracers/native"
-
 	"github.com/ethereum/go-ethereum/crypto"
 
 	"github.com/ethereum/go-ethereum/accounts/abi"
@@ -29,19 +29,19 @@ import (
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/consensus"
 	"github.com/ethereum/go-ethereum/core"
-	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
-	"github.com/ethereum/go-ethereum/ethdb/memorydb"
 	"github.com/ethereum/go-ethereum/params"
 
 	"github.com/holiman/uint256"
 )
 
 type artifactData struct {
-	Bytecode         string `json:"bytecode"`
-	DeployedBytecode string `json:"deployedBytecode"`
+	Bytecode         string          `json:"bytecode"`
+	DeployedBytecode string          `json:"deployedBytecode"`
+	ABI              json.RawMessage `json:"abi"`
+	StorageLayout    *storageLayout  `json:"storageLayout"`
 }
 
 func (a *artifactData) UnmarshalJSON(b []byte) error {
@@ -52,6 +52,8 @@ func (a *artifactData) UnmarshalJSON(b []byte) error {
 		DeployedBytecode struct {
 			Object string `json:"object"`
 		} `json:"deployedBytecode"`
+		ABI           json.RawMessage `json:"abi"`
+		StorageLayout *storageLayout  `json:"storageLayout"`
 	}
 	if err := json.Unmarshal(b, &s); err != nil {
 		return err
@@ -59,10 +61,32 @@ func (a *artifactData) UnmarshalJSON(b []byte) error {
 
 	a.Bytecode = s.Bytecode.Object
 	a.DeployedBytecode = s.DeployedBytecode.Object
+	a.ABI = s.ABI
+	a.StorageLayout = s.StorageLayout
 
 	return nil
 }
 
+func parseArtifact(rawArtifact []byte) (*artifactData, error) {
+	artifact := &artifactData{}
+	if err := json.Unmarshal(rawArtifact, artifact); err != nil {
+		return nil, err
+	}
+	return artifact, nil
+}
+
+func mustParseArtifactABI(rawArtifact []byte) abi.ABI {
+	artifact, err := parseArtifact(rawArtifact)
+	if err != nil {
+		panic(err)
+	}
+	result, err := abi.JSON(bytes.NewReader(artifact.ABI))
+	if err != nil {
+		panic(err)
+	}
+	return result
+}
+
 type dummyChainContext struct {
 }
 
@@ -94,73 +118,168 @@ func readDirtyStorageFromState(f *state.StateObject) state.Storage {
 	return result
 }
 
-func simulateSystemContract(genesis *core.Genesis, systemContract common.Address, rawArtifact []byte, constructor []byte, balance *big.Int) error {
-	artifact := &artifactData{}
-	if err := json.Unmarshal(rawArtifact, artifact); err != nil {
-		return err
-	}
-	bytecode := append(hexutil.MustDecode(artifact.Bytecode), constructor...)
-	// simulate constructor execution
-	ethdb := rawdb.NewDatabase(memorydb.New())
-	db := state.NewDatabaseWithConfig(ethdb, &triedb.Config{})
-	statedb, err := state.New(common.Hash{}, db, nil)
-	if err != nil {
-		return err
+// defaultCtorGasLimit is a gas limit for system contract's constructor and init function
+const defaultCtorGasLimit = 10_000_000
+
+type ctorSimulationOptions struct {
+	GasLimit uint64
+	// Trace is nil if tracing is disabled
+	Trace *ctorTraceConfig
+}
+
+// systemContractReport contains gas usage and code sizes collected while simulating system contract deployment
+type systemContractReport struct {
+	Name         string         `json:"name"`
+	Address      common.Address `json:"address"`
+	CtorGasUsed  uint64         `json:"ctorGasUsed"`
+	InitGasUsed  uint64         `json:"initGasUsed"`
+	GasLimit     uint64         `json:"gasLimit"`
+	CodeSize     int            `json:"codeSize"`
+	InitCodeSize int            `json:"initCodeSize"`
+	// CodeHash is a hash of the runtime code w/o metadata, Release is its name in the code registry
+	CodeHash common.Hash `json:"codeHash"`
+	Release  string      `json:"release"`
+	// initStorage is a storage after init function, it's used to compare shared and isolated simulations
+	initStorage state.Storage
+}
+
+func (r *systemContractReport) exceedsCodeSize() bool {
+	return r.CodeSize > params.MaxCodeSize
+}
+
+func (r *systemContractReport) exceedsInitCodeSize() bool {
+	return r.InitCodeSize > params.MaxInitCodeSize
+}
+
+func simulationError(name string, stage string, gasLimit uint64, ret []byte, err error) error {
+	if errors.Is(err, vm.ErrOutOfGas) || errors.Is(err, vm.ErrCodeStoreOutOfGas) {
+		return fmt.Errorf("%s %s ran out of gas (limit is %d), increase ctorGasLimit in the config: %w", name, stage, gasLimit, err)
 	}
-	statedb.SetBalance(systemContract, uint256.MustFromBig(balance))
-	block := genesis.ToBlock()
-	blockContext := core.NewEVMBlockContext(block.Header(), &dummyChainContext{}, &common.Address{})
+	return fmt.Errorf("%s %s failed: %s", name, stage, describeCallError(ret, err))
+}
+
+// newSystemContractReport creates report of the system contract simulation, name is resolved by the address
+func newSystemContractReport(systemContract common.Address, gasLimit uint64, bytecode []byte) *systemContractReport {
+	return &systemContractReport{Name: systemContractName(systemContract), Address: systemContract, GasLimit: gasLimit, InitCodeSize: len(bytecode)}
+}
+
+func newSystemContractEVM(header *types.Header, chainConfig *params.ChainConfig, statedb *state.StateDB, systemContract common.Address, gasLimit uint64) *vm.EVM {
+	blockContext := core.NewEVMBlockContext(header, &dummyChainContext{}, &common.Address{})
 
 	msg := &core.Message{
 		From:              common.Address{},
 		To:                &systemContract,
 		Nonce:             0,
 		Value:             big.NewInt(0),
-		GasLimit:          10_000_000,
+		GasLimit:          gasLimit,
 		GasPrice:          big.NewInt(0),
 		Data:              []byte{},
 		AccessList:        nil,
 		SkipAccountChecks: false,
 	}
 	txContext := core.NewEVMTxContext(msg)
+	return vm.NewEVM(blockContext, txContext, statedb, chainConfig, vm.Config{})
+}
+
+// deploySystemContract executes constructor of the system contract, runtime code is returned
+func deploySystemContract(evm *vm.EVM, report *systemContractReport, artifact *artifactData, bytecode []byte, options ctorSimulationOptions) ([]byte, error) {
+	gasLimit := options.GasLimit
+	tracer, err := options.Trace.start(evm, gasLimit)
+	if err != nil {
+		return nil, err
+	}
+	deployedBytecode, leftOverGas, err := evm.CreateWithAddress(vm.AccountRef(common.Address{}), bytecode, gasLimit, big.NewInt(0), report.Address)
+	report.CtorGasUsed = gasLimit - leftOverGas
+	if err := options.Trace.write(tracer, report.Name, "ctor", leftOverGas); err != nil {
+		return nil, err
+	}
+	if err != nil {
+		return nil, simulationError(report.Name, "constructor", gasLimit, deployedBytecode, err)
+	}
+	report.CodeSize = len(deployedBytecode)
+	report.CodeHash, report.Release = runtimeCodeHash(deployedBytecode), knownCodeReleases.release(report.Name, deployedBytecode)
+	// constructor must return exactly the artifact's runtime code, otherwise the artifact is stale or corrupted
+	if !bytes.Equal(deployedBytecode, hexutil.MustDecode(artifact.DeployedBytecode)) {
+		return nil, fmt.Errorf("runtime code of %s produced by constructor doesn't match deployedBytecode of the artifact", report.Name)
+	}
+	return deployedBytecode, nil
+}
+
+// initSystemContract calls init function of the deployed system contract, storage after init is kept in the report
+func initSystemContract(evm *vm.EVM, report *systemContractReport, rawArtifact []byte, options ctorSimulationOptions) error {
+	gasLimit := options.GasLimit
+	initInput, err := mustParseArtifactABI(rawArtifact).Pack("init")
 	if err != nil {
 		return err
 	}
-	evm := vm.NewEVM(blockContext, txContext, statedb, genesis.Config, vm.Config{})
-	deployedBytecode, _, err := evm.CreateWithAddress(vm.AccountRef(common.Address{}), bytecode, 10_000_000, big.NewInt(0), systemContract)
+	tracer, err := options.Trace.start(evm, gasLimit)
 	if err != nil {
-		for _, c := range deployedBytecode[64:] {
-			if c >= 32 && c <= unicode.MaxASCII {
-				print(string(c))
-			}
-		}
-		println()
 		return err
 	}
-	storage := readDirtyStorageFromState(statedb.GetOrNewStateObject(systemContract))
+	errorCode, leftOverGas, err := evm.Call(vm.AccountRef(common.Address{}), report.Address, initInput, gasLimit, uint256.MustFromBig(big.NewInt(0)))
+	report.InitGasUsed = gasLimit - leftOverGas
+	if err := options.Trace.write(tracer, report.Name, "init", leftOverGas); err != nil {
+		return err
+	}
+	if err != nil {
+		return simulationError(report.Name, "init", gasLimit, errorCode, err)
+	}
+	return nil
+}
+
+// readSystemContractStorage reads storage written by the simulation, state isn't committed so all changes are dirty
+func readSystemContractStorage(statedb *state.StateDB, systemContract common.Address) state.Storage {
+	return readDirtyStorageFromState(statedb.GetOrNewStateObject(systemContract)).Copy()
+}
+
+// simulateSystemContract executes constructor and init function of the system contract in its own state,
+// so contracts can be simulated concurrently, the resulting genesis account is returned
+func simulateSystemContract(header *types.Header, chainConfig *params.ChainConfig, systemContract common.Address, rawArtifact []byte, constructor []byte, balance *big.Int, options ctorSimulationOptions) (*systemContractReport, core.GenesisAccount, error) {
+	artifact, err := parseArtifact(rawArtifact)
+	if err != nil {
+		return nil, core.GenesisAccount{}, err
+	}
+	bytecode := append(hexutil.MustDecode(artifact.Bytecode), constructor...)
+	report := newSystemContractReport(systemContract, options.GasLimit, bytecode)
+	// simulate constructor execution
+	statedb, err := newSimulationStateDB(nil)
+	if err != nil {
+		return nil, core.GenesisAccount{}, err
+	}
+	statedb.SetBalance(systemContract, uint256.MustFromBig(balance))
+	evm := newSystemContractEVM(header, chainConfig, statedb, systemContract, options.GasLimit)
+	deployedBytecode, err := deploySystemContract(evm, report, artifact, bytecode, options)
+	if err != nil {
+		return report, core.GenesisAccount{}, err
+	}
 	// read state changes from state database
 	genesisAccount := core.GenesisAccount{
 		Code:    deployedBytecode,
-		Storage: storage.Copy(),
+		Storage: readSystemContractStorage(statedb, systemContract),
 		Balance: big.NewInt(0),
 		Nonce:   0,
 	}
-	if genesis.Alloc == nil {
-		genesis.Alloc = make(core.GenesisAlloc)
-	}
-	genesis.Alloc[systemContract] = genesisAccount
 	// make sure ctor working fine (better to fail here instead of in consensus engine)
-	errorCode, _, err := evm.Call(vm.AccountRef(common.Address{}), systemContract, hexutil.MustDecode("0xe1c7392a"), 10_000_000, uint256.MustFromBig(big.NewInt(0)))
-	if err != nil {
-		for _, c := range errorCode[64:] {
-			if c >= 32 && c <= unicode.MaxASCII {
-				print(string(c))
-			}
+	if err := initSystemContract(evm, report, rawArtifact, options); err != nil {
+		return report, core.GenesisAccount{}, err
+	}
+	report.initStorage = readSystemContractStorage(statedb, systemContract)
+	return report, genesisAccount, nil
+}
+
+func printSystemContractReports(w io.Writer, reports []*systemContractReport) {
+	fmt.Fprintf(w, "%-18s %-42s %12s %12s %14s %16s  %s\n", "contract", "address", "ctor gas", "init gas", "code size", "initcode size", "release")
+	for _, r := range reports {
+		codeSize := fmt.Sprintf("%d/%d", r.CodeSize, params.MaxCodeSize)
+		if r.exceedsCodeSize() {
+			codeSize = "!" + codeSize
 		}
-		println()
-		return err
+		initCodeSize := fmt.Sprintf("%d/%d", r.InitCodeSize, params.MaxInitCodeSize)
+		if r.exceedsInitCodeSize() {
+			initCodeSize = "!" + initCodeSize
+		}
+		fmt.Fprintf(w, "%-18s %-42s %12d %12d %14s %16s  %s\n", r.Name, r.Address.Hex(), r.CtorGasUsed, r.InitGasUsed, codeSize, initCodeSize, r.Release)
 	}
-	return nil
 }
 
 var stakingAddress = common.HexToAddress("0x0000000000000000000000000000000000001000")
@@ -201,6 +320,58 @@ var deployerProxyRawArtifact []byte
 //go:embed out/Tokenomics.sol/Tokenomics.json
 var tokenomicsRawArtifact []byte
 
+type systemContract struct {
+	Name        string
+	Address     common.Address
+	RawArtifact []byte
+}
+
+var systemContracts = []systemContract{
+	{Name: "Staking", Address: stakingAddress, RawArtifact: stakingRawArtifact},
+	{Name: "SlashingIndicator", Address: slashingIndicatorAddress, RawArtifact: slashingIndicatorRawArtifact},
+	{Name: "SystemReward", Address: systemRewardAddress, RawArtifact: systemRewardRawArtifact},
+	{Name: "StakingPool", Address: stakingPoolAddress, RawArtifact: stakingPoolRawArtifact},
+	{Name: "Governance", Address: governanceAddress, RawArtifact: governanceRawArtifact},
+	{Name: "ChainConfig", Address: chainConfigAddress, RawArtifact: chainConfigRawArtifact},
+	{Name: "RuntimeUpgrade", Address: runtimeUpgradeAddress, RawArtifact: runtimeUpgradeRawArtifact},
+	{Name: "DeployerProxy", Address: deployerProxyAddress, RawArtifact: deployerProxyRawArtifact},
+	{Name: "Tokenomics", Address: tokenomicsAddress, RawArtifact: tokenomicsRawArtifact},
+}
+
+func systemContractByAddress(address common.Address) (systemContract, bool) {
+	for _, c := range systemContracts {
+		if c.Address == address {
+			return c, true
+		}
+	}
+	return systemContract{}, false
+}
+
+// systemContractName returns name of the system contract or its address if contract is unknown
+func systemContractName(address common.Address) string {
+	if c, ok := systemContractByAddress(address); ok {
+		return c.Name
+	}
+	return address.Hex()
+}
+
+func systemContractByName(name string) (systemContract, bool) {
+	for _, c := range systemContracts {
+		if strings.EqualFold(c.Name, name) {
+			return c, true
+		}
+	}
+	return systemContract{}, false
+}
+
+// readSystemContractArtifact reads artifact from forge's output directory, if directory is empty then embedded artifact is used
+func readSystemContractArtifact(artifactsDir string, contract systemContract) ([]byte, error) {
+	if artifactsDir == "" {
+		return contract.RawArtifact, nil
+	}
+	return os.ReadFile(filepath.Join(artifactsDir, contract.Name+".sol", contract.Name+".json"))
+}
+
 func newArguments(typeNames ...string) abi.Arguments {
 	var args abi.Arguments
 	for i, tn := range typeNames {
@@ -214,19 +385,19 @@ func newArguments(typeNames ...string) abi.Arguments {
 }
 
 type consensusParams struct {
-	ActiveValidatorsLength   uint32                `json:"activeValidatorsLength"`
-	EpochBlockInterval       uint32                `json:"epochBlockInterval"`
-	MisdemeanorThreshold     uint32                `json:"misdemeanorThreshold"`
-	FelonyThreshold          uint32                `json:"felonyThreshold"`
-	ValidatorJailEpochLength uint32                `json:"validatorJailEpochLength"`
-	UndelegatePeriod         uint32                `json:"undelegatePeriod"`
-	MinValidatorStakeAmount  *math.HexOrDecimal256 `json:"minValidatorStakeAmount"`
-	MinStakingAmount         *math.HexOrDecimal256 `json:"minStakingAmount"`
+	ActiveValidatorsLength   uint32                `json:"activeValidatorsLength"`   // max number of active validators
+	EpochBlockInterval       uint32                `json:"epochBlockInterval"`       // epoch length in blocks
+	MisdemeanorThreshold     uint32                `json:"misdemeanorThreshold"`     // missed blocks per epoch after which validator loses rewards
+	FelonyThreshold          uint32                `json:"felonyThreshold"`          // missed blocks per epoch after which validator goes in jail
+	ValidatorJailEpochLength uint32                `json:"validatorJailEpochLength"` // how many epochs validator stays in jail
+	UndelegatePeriod         uint32                `json:"undelegatePeriod"`         // how many epochs undelegated funds are locked
+	MinValidatorStakeAmount  *math.HexOrDecimal256 `json:"minValidatorStakeAmount"`  // min stake of the validator (in wei)
+	MinStakingAmount         *math.HexOrDecimal256 `json:"minStakingAmount"`         // min delegation amount (in wei)
 }
 
 type tokenomicsParams struct {
-	StakingShare       uint16 `json:"stakingShare"`
-	SystemRewardsShare uint16 `json:"systemRewardsShare"`
+	StakingShare       uint16 `json:"stakingShare"`       // share of the rewards paid to stakers (10000 is 100%)
+	SystemRewardsShare uint16 `json:"systemRewardsShare"` // share of the rewards paid to the system treasury (10000 is 100%)
 }
 
 type ChilizForks struct {
@@ -236,74 +407,118 @@ type ChilizForks struct {
 	DeployerFactoryBlock   *math.HexOrDecimal256 `json:"deployerFactoryBlock"`
 	Dragon8Time            uint64                `json:"dragon8Time,omitempty"`
 	Dragon8FixTime         uint64                `json:"dragon8FixTime,omitempty"`
+	// BSC fast finality forks, extraData carries BLS vote addresses if Luban is active at genesis
+	LubanBlock *math.HexOrDecimal256 `json:"lubanBlock,omitempty"`
+	PlatoBlock *math.HexOrDecimal256 `json:"platoBlock,omitempty"`
 }
 
 type genesisConfig struct {
-	ChainId          int64                     `json:"chainId"`
-	Deployers        []common.Address          `json:"deployers"`
-	Validators       []common.Address          `json:"validators"`
-	SystemTreasury   map[common.Address]uint16 `json:"systemTreasury"`
-	ConsensusParams  consensusParams           `json:"consensusParams"`
-	TokenomicsParams tokenomicsParams          `json:"tokenomicsParams"`
-	VotingPeriod     int64                     `json:"votingPeriod"`
-	Faucet           map[common.Address]string `json:"faucet"`
-	CommissionRate   int64                     `json:"commissionRate"`
-	InitialStakes    map[common.Address]string `json:"initialStakes"`
-	Forks            ChilizForks               `json:"forks"`
-}
-
-func invokeConstructorOrPanic(genesis *core.Genesis, contract common.Address, rawArtifact []byte, typeNames []string, params []interface{}, silent bool, balance *big.Int) {
+	ChainId int64 `json:"chainId"`
+	// who is able to deploy smart contract from genesis block
+	Deployers []common.Address `json:"deployers"`
+	// list of genesis validators
+	Validators []common.Address `json:"validators"`
+	// BLS public keys (48 bytes) used by validators for fast finality votes, required if Luban is active at genesis
+	VoteAddresses    map[common.Address]hexutil.Bytes `json:"voteAddresses,omitempty"`
+	SystemTreasury   map[common.Address]uint16        `json:"systemTreasury"` // system reward shares of treasury accounts (10000 is 100%)
+	ConsensusParams  consensusParams                  `json:"consensusParams"`
+	TokenomicsParams tokenomicsParams                 `json:"tokenomicsParams"`
+	VotingPeriod     int64                            `json:"votingPeriod"`   // governance voting period in blocks
+	Faucet           map[common.Address]string        `json:"faucet"`         // genesis balances (hex in wei)
+	CommissionRate   int64                            `json:"commissionRate"` // commission rate of genesis validators (10000 is 100%)
+	InitialStakes    map[common.Address]string        `json:"initialStakes"`  // initial stakes of genesis validators (hex in wei)
+	Forks            ChilizForks                      `json:"forks"`
+	// gas limit for every system contract's constructor and init function (10kk by default)
+	CtorGasLimit uint64 `json:"ctorGasLimit,omitempty"`
+	// validators order: strict (ascending order is required, default), sort (sort with a warning) or legacy (keep config order)
+	ValidatorOrdering string `json:"validatorOrdering,omitempty"`
+	// human-readable labels of faucet accounts used by the supply report
+	FaucetLabels map[common.Address]string `json:"faucetLabels,omitempty"`
+	// expected total supply, cap and label totals checked by the build
+	Supply *supplyConstraints `json:"supply,omitempty"`
+	// CSV or JSON files with address, amount and label columns merged into the genesis alloc
+	Allocations []string `json:"allocations,omitempty"`
+	// opt-in execution tracing of system contract's constructor and init function
+	Trace *ctorTraceConfig `json:"trace,omitempty"`
+	// deploy all system contracts into one state in dependency order, so constructors and init functions see each other
+	SharedState bool `json:"sharedState,omitempty"`
+}
+
+// packConstructor encodes ctor params of the system contract, ctor signature is returned for logging
+func packConstructor(typeNames []string, params []interface{}) ([]byte, []byte, error) {
 	ctor, err := newArguments(typeNames...).Pack(params...)
 	if err != nil {
-		panic(err)
+		return nil, nil, err
 	}
 	sig := crypto.Keccak256([]byte(fmt.Sprintf("ctor(%s)", strings.Join(typeNames, ","))))[:4]
 	ctor = append(sig, ctor...)
 	ctor, err = newArguments("bytes").Pack(ctor)
 	if err != nil {
-		panic(err)
-	}
-	if !silent {
-		fmt.Printf(" + calling constructor: address=%s sig=%s ctor=%s\n", contract.Hex(), hexutil.Encode(sig), hexutil.Encode(ctor))
-	}
-	if err := simulateSystemContract(genesis, contract, rawArtifact, ctor, balance); err != nil {
-		panic(err)
+		return nil, nil, err
 	}
+	return sig, ctor, nil
 }
 
-func createGenesisConfig(config genesisConfig, targetFile string, updateOnlyConfig bool) error {
-	suppressLogging := targetFile == "stdout"
-	var genesis *core.Genesis
-	if updateOnlyConfig {
-		genesis, _ = existingGenesisConfigOrDefault(config, targetFile, suppressLogging)
-	} else {
-		genesis = defaultGenesisConfig(config)
+// genesisBuildReport is a machine-readable summary of the genesis build
+type genesisBuildReport struct {
+	ChainId         int64                   `json:"chainId"`
+	SystemContracts []*systemContractReport `json:"systemContracts"`
+	Allocations     []allocationLabelTotal  `json:"allocations,omitempty"`
+	AllocMerges     []string                `json:"allocMerges,omitempty"`
+	Warnings        []string                `json:"warnings,omitempty"`
+	// SharedStateDiffs lists differences of the shared state simulation from the isolated one
+	SharedStateDiffs []string      `json:"sharedStateDiffs,omitempty"`
+	Supply           *supplyReport `json:"supply"`
+}
+
+func createGenesisConfig(config genesisConfig, targetFile string, updateOnlyConfig bool) (*genesisBuildReport, error) {
+	genesis, report, err := buildGenesis(config, targetFile, updateOnlyConfig, buildLog(targetFile == "stdout"))
+	if err != nil {
+		return nil, err
 	}
-	// extra data
-	genesis.ExtraData = createExtraData(config.Validators)
-	genesis.Config.Parlia.Epoch = uint64(config.ConsensusParams.EpochBlockInterval)
-	// execute system contracts
+	// save to file
+	newJson, _ := json.MarshalIndent(genesis, "", "  ")
+	return report, writeOutputFile(targetFile, newJson)
+}
+
+// systemContractCtor is a constructor call of the system contract at genesis
+type systemContractCtor struct {
+	Address     common.Address
+	RawArtifact []byte
+	TypeNames   []string
+	Params      []interface{}
+	// Balance is an initial balance of the contract (total initial stake for Staking)
+	Balance *big.Int
+}
+
+// genesisConstructors returns constructor calls of all system contracts in deployment order, Staking goes first
+func genesisConstructors(config genesisConfig) ([]systemContractCtor, error) {
 	var initialStakes []*big.Int
 	initialStakeTotal := big.NewInt(0)
 	for _, v := range config.Validators {
 		rawInitialStake, ok := config.InitialStakes[v]
 		if !ok {
-			return fmt.Errorf("initial stake is not found for validator: %s", v.Hex())
+			return nil, fmt.Errorf("initial stake is not found for validator: %s", v.Hex())
 		}
 		initialStake, err := hexutil.DecodeBig(rawInitialStake)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		initialStakes = append(initialStakes, initialStake)
 		initialStakeTotal.Add(initialStakeTotal, initialStake)
 	}
-	if genesis.Alloc == nil {
-		invokeConstructorOrPanic(genesis, stakingAddress, stakingRawArtifact, []string{"address[]", "uint256[]", "uint16"}, []interface{}{
+	treasuryAddresses := sortedAddresses(config.SystemTreasury)
+	var treasuryShares []uint16
+	for _, k := range treasuryAddresses {
+		treasuryShares = append(treasuryShares, config.SystemTreasury[k])
+	}
+	return []systemContractCtor{
+		{Address: stakingAddress, RawArtifact: stakingRawArtifact, TypeNames: []string{"address[]", "uint256[]", "uint16"}, Params: []interface{}{
 			config.Validators,
 			initialStakes,
 			uint16(config.CommissionRate),
-		}, suppressLogging, initialStakeTotal)
-		invokeConstructorOrPanic(genesis, chainConfigAddress, chainConfigRawArtifact, []string{"uint32", "uint32", "uint32", "uint32", "uint32", "uint32", "uint256", "uint256"}, []interface{}{
+		}, Balance: initialStakeTotal},
+		{Address: chainConfigAddress, RawArtifact: chainConfigRawArtifact, TypeNames: []string{"uint32", "uint32", "uint32", "uint32", "uint32", "uint32", "uint256", "uint256"}, Params: []interface{}{
 			config.ConsensusParams.ActiveValidatorsLength,
 			config.ConsensusParams.EpochBlockInterval,
 			config.ConsensusParams.MisdemeanorThreshold,
@@ -312,59 +527,207 @@ func createGenesisConfig(config genesisConfig, targetFile string, updateOnlyConf
 			config.ConsensusParams.UndelegatePeriod,
 			(*big.Int)(config.ConsensusParams.MinValidatorStakeAmount),
 			(*big.Int)(config.ConsensusParams.MinStakingAmount),
-		}, suppressLogging, nil)
-		invokeConstructorOrPanic(genesis, slashingIndicatorAddress, slashingIndicatorRawArtifact, []string{}, []interface{}{}, suppressLogging, nil)
-		invokeConstructorOrPanic(genesis, stakingPoolAddress, stakingPoolRawArtifact, []string{}, []interface{}{}, suppressLogging, nil)
-		var treasuryAddresses []common.Address
-		var treasuryShares []uint16
-		for k, v := range config.SystemTreasury {
-			treasuryAddresses = append(treasuryAddresses, k)
-			treasuryShares = append(treasuryShares, v)
-		}
-		invokeConstructorOrPanic(genesis, systemRewardAddress, systemRewardRawArtifact, []string{"address[]", "uint16[]"}, []interface{}{
+		}},
+		{Address: slashingIndicatorAddress, RawArtifact: slashingIndicatorRawArtifact, TypeNames: []string{}, Params: []interface{}{}},
+		{Address: stakingPoolAddress, RawArtifact: stakingPoolRawArtifact, TypeNames: []string{}, Params: []interface{}{}},
+		{Address: systemRewardAddress, RawArtifact: systemRewardRawArtifact, TypeNames: []string{"address[]", "uint16[]"}, Params: []interface{}{
 			treasuryAddresses, treasuryShares,
-		}, suppressLogging, nil)
-		invokeConstructorOrPanic(genesis, governanceAddress, governanceRawArtifact, []string{"uint256"}, []interface{}{
+		}},
+		{Address: governanceAddress, RawArtifact: governanceRawArtifact, TypeNames: []string{"uint256"}, Params: []interface{}{
 			big.NewInt(config.VotingPeriod),
-		}, suppressLogging, nil)
-		invokeConstructorOrPanic(genesis, runtimeUpgradeAddress, runtimeUpgradeRawArtifact, []string{"address"}, []interface{}{
+		}},
+		{Address: runtimeUpgradeAddress, RawArtifact: runtimeUpgradeRawArtifact, TypeNames: []string{"address"}, Params: []interface{}{
 			systemcontract.EvmHookRuntimeUpgradeAddress,
-		}, suppressLogging, nil)
-		invokeConstructorOrPanic(genesis, deployerProxyAddress, deployerProxyRawArtifact, []string{"address[]"}, []interface{}{
+		}},
+		{Address: deployerProxyAddress, RawArtifact: deployerProxyRawArtifact, TypeNames: []string{"address[]"}, Params: []interface{}{
 			config.Deployers,
-		}, suppressLogging, nil)
-		invokeConstructorOrPanic(genesis, tokenomicsAddress, tokenomicsRawArtifact, []string{"uint16", "uint16"}, []interface{}{
+		}},
+		{Address: tokenomicsAddress, RawArtifact: tokenomicsRawArtifact, TypeNames: []string{"uint16", "uint16"}, Params: []interface{}{
 			config.TokenomicsParams.StakingShare, config.TokenomicsParams.SystemRewardsShare,
-		}, suppressLogging, nil)
+		}},
+	}, nil
+}
+
+// simulateIsolatedSystemContracts simulates every system contract in its own state concurrently, errors are returned per contract
+func simulateIsolatedSystemContracts(header *types.Header, chainConfig *params.ChainConfig, ctors []systemContractCtor, inputs [][]byte, options ctorSimulationOptions) ([]*systemContractReport, []core.GenesisAccount, []error) {
+	reports := make([]*systemContractReport, len(ctors))
+	accounts := make([]core.GenesisAccount, len(ctors))
+	errs := make([]error, len(ctors))
+	runParallel(len(ctors), func(i int) error {
+		reports[i], accounts[i], errs[i] = simulateSystemContract(header, chainConfig, ctors[i].Address, ctors[i].RawArtifact, inputs[i], ctors[i].Balance, options)
+		return nil
+	})
+	return reports, accounts, errs
+}
+
+// buildLog returns writer for the build log, logs are discarded if the genesis is written to stdout
+func buildLog(silent bool) io.Writer {
+	if silent {
+		return io.Discard
+	}
+	return os.Stdout
+}
+
+// buildGenesis creates genesis from the config, if only config must be updated then alloc of the existing genesis file is kept,
+// isolated system contract simulations run concurrently while the log is written in deployment order
+func buildGenesis(config genesisConfig, existingGenesisFile string, updateOnlyConfig bool, log io.Writer) (*core.Genesis, *genesisBuildReport, error) {
+	report := &genesisBuildReport{ChainId: config.ChainId}
+	ctorOptions := ctorSimulationOptions{GasLimit: config.CtorGasLimit, Trace: config.Trace}
+	if ctorOptions.GasLimit == 0 {
+		ctorOptions.GasLimit = defaultCtorGasLimit
+	}
+	if ctorOptions.Trace != nil {
+		trace, err := ctorOptions.Trace.prepare(config.ChainId)
+		if err != nil {
+			return nil, nil, err
+		}
+		ctorOptions.Trace = trace
+	}
+	allocations, err := readAllocationFiles(config.Allocations)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := validateAllocations(allocations); err != nil {
+		return nil, nil, err
+	}
+	var genesis *core.Genesis
+	if updateOnlyConfig {
+		genesis, _ = existingGenesisConfigOrDefault(config, existingGenesisFile, log)
+	} else {
+		genesis = defaultGenesisConfig(config)
+	}
+	// extra data
+	validators, warnings, err := canonicalValidatorSet(config)
+	if err != nil {
+		return nil, nil, err
+	}
+	config.Validators = validators
+	report.Warnings = append(report.Warnings, warnings...)
+	if err := validateVoteAddresses(config); err != nil {
+		return nil, nil, err
+	}
+	if genesis.Config.IsLuban(common.Big0) {
+		genesis.ExtraData = createLubanExtraData(config.Validators, config.VoteAddresses)
+	} else {
+		genesis.ExtraData = createExtraData(config.Validators)
+	}
+	genesis.Config.Parlia.Epoch = uint64(config.ConsensusParams.EpochBlockInterval)
+	// execute system contracts
+	constructors, err := genesisConstructors(config)
+	if err != nil {
+		return nil, nil, err
+	}
+	if genesis.Alloc == nil {
+		inputs := make([][]byte, len(constructors))
+		for i, ctor := range constructors {
+			sig, input, err := packConstructor(ctor.TypeNames, ctor.Params)
+			if err != nil {
+				return nil, nil, err
+			}
+			inputs[i] = input
+			fmt.Fprintf(log, " + calling constructor: address=%s sig=%s ctor=%s\n", ctor.Address.Hex(), hexutil.Encode(sig), hexutil.Encode(input))
+		}
+		// block context doesn't depend on alloc, so the header is created once for all simulations
+		header := genesis.ToBlock().Header()
+		var accounts []core.GenesisAccount
+		if config.SharedState {
+			order, err := sharedStateOrder(constructors)
+			if err != nil {
+				return nil, nil, err
+			}
+			var names []string
+			for _, i := range order {
+				names = append(names, systemContractName(constructors[i].Address))
+			}
+			fmt.Fprintf(log, " + simulating system contracts in shared state: %s\n", strings.Join(names, ", "))
+			if report.SystemContracts, accounts, err = simulateSharedSystemContracts(header, genesis.Config, constructors, inputs, order, ctorOptions); err != nil {
+				return nil, nil, err
+			}
+			// isolated simulation isn't traced, otherwise it would overwrite traces of the shared one
+			isolated, isolatedAccounts, isolatedErrs := simulateIsolatedSystemContracts(header, genesis.Config, constructors, inputs, ctorSimulationOptions{GasLimit: ctorOptions.GasLimit})
+			report.SharedStateDiffs = compareSharedState(report.SystemContracts, accounts, isolated, isolatedAccounts, isolatedErrs)
+			for _, diff := range report.SharedStateDiffs {
+				fmt.Fprintf(log, " ~ %s\n", diff)
+			}
+			if len(report.SharedStateDiffs) == 0 {
+				fmt.Fprintf(log, " + shared state simulation matches isolated simulation\n")
+			}
+		} else {
+			var errs []error
+			report.SystemContracts, accounts, errs = simulateIsolatedSystemContracts(header, genesis.Config, constructors, inputs, ctorOptions)
+			if err := errors.Join(errs...); err != nil {
+				return nil, nil, err
+			}
+		}
+		genesis.Alloc = make(core.GenesisAlloc)
+		for i, ctor := range constructors {
+			genesis.Alloc[ctor.Address] = accounts[i]
+		}
 		// create system contract
 		genesis.Alloc[intermediarySystemAddress] = core.GenesisAccount{
 			Balance: big.NewInt(0),
 		}
 		// set staking allocation
 		stakingAlloc := genesis.Alloc[stakingAddress]
-		stakingAlloc.Balance = initialStakeTotal
+		stakingAlloc.Balance = constructors[0].Balance
 		genesis.Alloc[stakingAddress] = stakingAlloc
-		// apply faucet
-		for key, value := range config.Faucet {
-			balance, ok := new(big.Int).SetString(value[2:], 16)
-			if !ok {
-				return fmt.Errorf("failed to parse number (%s)", value)
-			}
-			genesis.Alloc[key] = core.GenesisAccount{
-				Balance: balance,
-			}
+		// apply faucet and imported allocations, balances of the same account are added up
+		merger := newAllocMerger(genesis.Alloc)
+		if err := addFaucetAccounts(merger, config); err != nil {
+			return nil, nil, err
+		}
+		for _, entry := range allocations {
+			merger.add(entry.Source, entry.Address, core.GenesisAccount{Balance: new(big.Int).Set(entry.Amount)})
+		}
+		if err := merger.err(); err != nil {
+			return nil, nil, err
+		}
+		report.AllocMerges = merger.merges
+		report.Allocations = allocationLabelTotals(allocations)
+	} else if len(config.Allocations) > 0 {
+		return nil, nil, fmt.Errorf("allocations can't be applied, alloc of the existing genesis (%s) is kept", existingGenesisFile)
+	} else {
+		warnings, err := keptAllocWarnings(config, genesis.Alloc)
+		if err != nil {
+			return nil, nil, err
 		}
+		report.Warnings = append(report.Warnings, warnings...)
 	}
-	// save to file
-	newJson, _ := json.MarshalIndent(genesis, "", "  ")
+	for _, merge := range report.AllocMerges {
+		fmt.Fprintf(log, " ~ %s\n", merge)
+	}
+	if len(report.SystemContracts) > 0 {
+		printSystemContractReports(log, report.SystemContracts)
+	}
+	if len(report.Allocations) > 0 {
+		printAllocationLabelTotals(log, report.Allocations)
+	}
+	warnings, err = checkGenesisActiveValidators(genesis, config.ValidatorOrdering)
+	if err != nil {
+		return nil, nil, err
+	}
+	report.Warnings = append(report.Warnings, warnings...)
+	for _, warning := range report.Warnings {
+		fmt.Fprintf(log, "WARN: %s\n", warning)
+	}
+	report.Supply = buildSupplyReport(config, genesis.Alloc, allocations)
+	printSupplyReport(log, report.Supply)
+	if err := checkSupplyConstraints(report.Supply, config.Supply); err != nil {
+		return nil, nil, err
+	}
+	return genesis, report, nil
+}
+
+// writeOutputFile writes data to the file, "stdout" and "stderr" are reserved for standard streams
+func writeOutputFile(targetFile string, data []byte) error {
 	if targetFile == "stdout" {
-		_, err := os.Stdout.Write(newJson)
+		_, err := os.Stdout.Write(data)
 		return err
 	} else if targetFile == "stderr" {
-		_, err := os.Stderr.Write(newJson)
+		_, err := os.Stderr.Write(data)
 		return err
 	}
-	return ioutil.WriteFile(targetFile, newJson, fs.ModePerm)
+	return ioutil.WriteFile(targetFile, data, fs.ModePerm)
 }
 
 func decimalToBigInt(value *math.HexOrDecimal256) *big.Int {
@@ -374,19 +737,15 @@ func decimalToBigInt(value *math.HexOrDecimal256) *big.Int {
 	return (*big.Int)(value)
 }
 
-func existingGenesisConfigOrDefault(config genesisConfig, existingGenesisFile string, silent bool) (*core.Genesis, bool) {
+func existingGenesisConfigOrDefault(config genesisConfig, existingGenesisFile string, log io.Writer) (*core.Genesis, bool) {
 	bytes, err := os.ReadFile(existingGenesisFile)
 	if err != nil {
-		if !silent {
-			fmt.Printf("WARN: failed to find existing genesis config (%s), re-creating", existingGenesisFile)
-		}
+		fmt.Fprintf(log, "WARN: failed to find existing genesis config (%s), re-creating\n", existingGenesisFile)
 		return defaultGenesisConfig(config), false
 	}
 	genesis := &core.Genesis{}
 	if err := json.Unmarshal(bytes, genesis); err != nil {
-		if !silent {
-			fmt.Printf("ERR: failed to parse existing genesis config (%s), re-creating: %v", existingGenesisFile, err)
-		}
+		fmt.Fprintf(log, "ERR: failed to parse existing genesis config (%s), re-creating: %v\n", existingGenesisFile, err)
 		return defaultGenesisConfig(config), false
 	}
 	defaultConfig := defaultGenesisConfig(config)
@@ -433,8 +792,8 @@ func defaultGenesisConfig(config genesisConfig) *core.Genesis {
 		GibbsBlock: big.NewInt(0),
 		// BSC 2023 forks
 		PlanckBlock:   big.NewInt(0),
-		LubanBlock:    nil,
-		PlatoBlock:    nil,
+		LubanBlock:    decimalToBigInt(config.Forks.LubanBlock),
+		PlatoBlock:    decimalToBigInt(config.Forks.PlatoBlock),
 		HertzBlock:    big.NewInt(0),
 		HertzfixBlock: big.NewInt(0),
 		// BSC 2024 forks
@@ -511,248 +870,108 @@ var localNetConfig = genesisConfig{
 	},
 }
 
-var devNetConfig = genesisConfig{
-	ChainId: 17243,
-	// who is able to deploy smart contract from genesis block (it won't generate event log)
-	Deployers: []common.Address{},
-	// list of default validators (it won't generate event log)
-	Validators: []common.Address{
-		common.HexToAddress("0x08fae3885e299c24ff9841478eb946f41023ac69"),
-		common.HexToAddress("0x751aaca849b09a3e347bbfe125cf18423cc24b40"),
-		common.HexToAddress("0xa6ff33e3250cc765052ac9d7f7dfebda183c4b9b"),
-		common.HexToAddress("0x49c0f7c8c11a4c80dc6449efe1010bb166818da8"),
-		common.HexToAddress("0x8e1ea6eaa09c3b40f4a51fcd056a031870a0549a"),
-	},
-	SystemTreasury: map[common.Address]uint16{
-		common.HexToAddress("0x0000000000000000000000000000000000000000"): 10000,
-	},
-	ConsensusParams: consensusParams{
-		ActiveValidatorsLength:   25,   // suggested values are (3k+1, where k is honest validators, even better): 7, 13, 19, 25, 31...
-		EpochBlockInterval:       1200, // better to use 1 day epoch (86400/3=28800, where 3s is block time)
-		MisdemeanorThreshold:     50,   // after missing this amount of blocks per day validator losses all daily rewards (penalty)
-		FelonyThreshold:          150,  // after missing this amount of blocks per day validator goes in jail for N epochs
-		ValidatorJailEpochLength: 7,    // how many epochs validator should stay in jail (7 epochs = ~7 days)
-		UndelegatePeriod:         6,    // allow claiming funds only after 6 epochs (~7 days)
-
-		MinValidatorStakeAmount: (*math.HexOrDecimal256)(hexutil.MustDecodeBig("0xde0b6b3a7640000")), // how many tokens validator must stake to create a validator (in ether)
-		MinStakingAmount:        (*math.HexOrDecimal256)(hexutil.MustDecodeBig("0xde0b6b3a7640000")), // minimum staking amount for delegators (in ether)
-	},
-	InitialStakes: map[common.Address]string{
-		common.HexToAddress("0x08fae3885e299c24ff9841478eb946f41023ac69"): "0x3635c9adc5dea00000", // 1000 eth
-		common.HexToAddress("0x751aaca849b09a3e347bbfe125cf18423cc24b40"): "0x3635c9adc5dea00000", // 1000 eth
-		common.HexToAddress("0xa6ff33e3250cc765052ac9d7f7dfebda183c4b9b"): "0x3635c9adc5dea00000", // 1000 eth
-		common.HexToAddress("0x49c0f7c8c11a4c80dc6449efe1010bb166818da8"): "0x3635c9adc5dea00000", // 1000 eth
-		common.HexToAddress("0x8e1ea6eaa09c3b40f4a51fcd056a031870a0549a"): "0x3635c9adc5dea00000", // 1000 eth
-	},
-	// owner of the governance
-	VotingPeriod: 60, // 3 minutes
-	// faucet
-	Faucet: map[common.Address]string{
-		common.HexToAddress("0x00a601f45688dba8a070722073b015277cf36725"): "0x21e19e0c9bab2400000",    // governance
-		common.HexToAddress("0xb891fe7b38f857f53a7b5529204c58d5c487280b"): "0x52b7d2dcc80cd2e4000000", // faucet (10kk)
-	},
+//go:embed configs/networks/*.json
+var networkConfigFiles embed.FS
+
+// mustReadNetworkConfig reads config of the built-in network, configs are overlays of configs/networks/base.json
+func mustReadNetworkConfig(name string) genesisConfig {
+	config, err := readGenesisConfig(networkConfigFiles.ReadFile, "configs/networks/"+name+".json")
+	if err != nil {
+		panic(err)
+	}
+	return *config
 }
 
-var testNetConfig = genesisConfig{
-	ChainId: 88880,
-	// who is able to deploy smart contract from genesis block (it won't generate event log)
-	Deployers: []common.Address{
-		common.HexToAddress("0x54E98ee51446505fcf69093E015Ee36034321104"),
-	},
-	// list of default validators (it won't generate event log)
-	Validators: []common.Address{
-		common.HexToAddress("0x86d12897C56Fe1dB08BDfB84Bc90f458ee7dC5cE"),
-		common.HexToAddress("0xE45D81a7EF9456A254aa4db010AAF6601a15B5B7"),
-		common.HexToAddress("0x76106F0857938684D24f2CE167EE11607dFaa57d"),
-		common.HexToAddress("0x48223C151df5dc1dBc2E24f17e77728358113705"),
-		common.HexToAddress("0x49CfDafF386FD2683d28678aBd53F11Dec23c76C"),
-	},
-	SystemTreasury: map[common.Address]uint16{
-		common.HexToAddress("0xde8712be934a6A4C7dDd17DC91669F51284f4b0c"): 10000,
-	},
-	ConsensusParams: consensusParams{
-		ActiveValidatorsLength:   5,
-		EpochBlockInterval:       1200,                                                                   // (~1hour)
-		MisdemeanorThreshold:     100,                                                                    // missed blocks per epoch
-		FelonyThreshold:          200,                                                                    // missed blocks per epoch
-		ValidatorJailEpochLength: 6,                                                                      // nb of epochs
-		UndelegatePeriod:         1,                                                                      // nb of epochs
-		MinValidatorStakeAmount:  (*math.HexOrDecimal256)(hexutil.MustDecodeBig("0x3635c9adc5dea00000")), // how many tokens validator must stake to create a validator (in ether)
-		MinStakingAmount:         (*math.HexOrDecimal256)(hexutil.MustDecodeBig("0xde0b6b3a7640000")),    // minimum staking amount for delegators (in ether)
-	},
-	InitialStakes: map[common.Address]string{
-		common.HexToAddress("0x86d12897C56Fe1dB08BDfB84Bc90f458ee7dC5cE"): "0x152D02C7E14AF6800000", // 100 000 eth
-		common.HexToAddress("0xE45D81a7EF9456A254aa4db010AAF6601a15B5B7"): "0x3635C9ADC5DEA00000",   // 1000 eth
-		common.HexToAddress("0x76106F0857938684D24f2CE167EE11607dFaa57d"): "0x3635C9ADC5DEA00000",   // 1000 eth
-		common.HexToAddress("0x48223C151df5dc1dBc2E24f17e77728358113705"): "0x3635C9ADC5DEA00000",   // 1000 eth
-		common.HexToAddress("0x49CfDafF386FD2683d28678aBd53F11Dec23c76C"): "0x2B5E3AF16B1880000",    // 50 eth
-	},
-	// owner of the governance
-	VotingPeriod: 1200, // (~1hour)
-	// faucet
-	Faucet: map[common.Address]string{
-		common.HexToAddress("0xb0c09bF51E04eDc7Bf198D61bB74CDa886878167"): "0x197D7361310E45C669F80000", // main
-		common.HexToAddress("0xc59181b702A7F3A8eCea27f30072B8dbCcC0c48a"): "0x33B2E3C9FD0803CE8000000",  // faucet
-	},
-	Forks: ChilizForks{
-		RuntimeUpgradeBlock:    (*math.HexOrDecimal256)(big.NewInt(0)),
-		DeployOriginBlock:      (*math.HexOrDecimal256)(big.NewInt(2849000)),
-		DeploymentHookFixBlock: (*math.HexOrDecimal256)(big.NewInt(6067300)),
-		DeployerFactoryBlock:   nil,
-	},
+var devNetConfig = mustReadNetworkConfig("devnet")
+
+var testNetConfig = mustReadNetworkConfig("testnet")
+
+var spicyConfig = mustReadNetworkConfig("spicy")
+
+var mainNetConfig = mustReadNetworkConfig("mainnet")
+
+// builtinNetwork is a network built when the tool runs w/o arguments
+type builtinNetwork struct {
+	Name        string
+	Title       string
+	Config      genesisConfig
+	GenesisFile string
+	// UpdateOnlyConfig keeps alloc of the existing genesis file, it's used for launched networks
+	UpdateOnlyConfig bool
 }
 
-var spicyConfig = genesisConfig{
-	ChainId: 88882,
-	// who is able to deploy smart contract from genesis block (it won't generate event log)
-	Deployers: []common.Address{
-		common.HexToAddress("0x02880217b082cC24D371eB5Bad0827D208bcBC6D"),
-	},
-	// list of default validators (it won't generate event log)
-	Validators: []common.Address{
-		common.HexToAddress("0xb1b5a8b8E2a263C0F497BC32a7cb6D27AEA921fc"),
-		common.HexToAddress("0x4dD74707f22b74EC872CA6AEB2a065E3d006B9d9"),
-		common.HexToAddress("0xBD6D190548bbF5C6920a826dF063A970Bd18f307"),
-		common.HexToAddress("0xeC2e502f77c4811f2ef477397235976b1371FCd3"),
-		common.HexToAddress("0x1cB3FC9e10fB5b845e53e5EaAE0bD561e662b0A5"),
-		common.HexToAddress("0xbdBF08393b66130B4b243863150A265b2A5Df642"),
-		common.HexToAddress("0x86f2BB174c450917A1b560c66525E64A1c9B6a04"),
-	},
-	SystemTreasury: map[common.Address]uint16{
-		common.HexToAddress("0x060eA461Cf7E78A38400dE9255687beb9b2c7298"): 10000,
-	},
-	ConsensusParams: consensusParams{
-		ActiveValidatorsLength:   5,
-		EpochBlockInterval:       7200,                                                                   // ~6 hours
-		MisdemeanorThreshold:     400,                                                                    // missed blocks per epoch
-		FelonyThreshold:          800,                                                                    // missed blocks per epoch
-		ValidatorJailEpochLength: 4,                                                                      // nb of epochs
-		UndelegatePeriod:         1,                                                                      // nb of epochs
-		MinValidatorStakeAmount:  (*math.HexOrDecimal256)(hexutil.MustDecodeBig("0x3635c9adc5dea00000")), // how many tokens validator must stake to create a validator (in ether)
-		MinStakingAmount:         (*math.HexOrDecimal256)(hexutil.MustDecodeBig("0xde0b6b3a7640000")),    // minimum staking amount for delegators (in ether)
-	},
-	InitialStakes: map[common.Address]string{
-		common.HexToAddress("0xb1b5a8b8E2a263C0F497BC32a7cb6D27AEA921fc"): "0x152D02C7E14AF6800000", // 100 000 CHZ
-		common.HexToAddress("0x4dD74707f22b74EC872CA6AEB2a065E3d006B9d9"): "0x3635C9ADC5DEA00000",   // 1000 CHZ
-		common.HexToAddress("0xBD6D190548bbF5C6920a826dF063A970Bd18f307"): "0x3635C9ADC5DEA00000",   // 1000 CHZ
-		common.HexToAddress("0xeC2e502f77c4811f2ef477397235976b1371FCd3"): "0x3635C9ADC5DEA00000",   // 1000 CHZ
-		common.HexToAddress("0x1cB3FC9e10fB5b845e53e5EaAE0bD561e662b0A5"): "0x3635C9ADC5DEA00000",   // 1000 CHZ
-		common.HexToAddress("0xbdBF08393b66130B4b243863150A265b2A5Df642"): "0x3635C9ADC5DEA00000",   // 1000 CHZ
-		common.HexToAddress("0x86f2BB174c450917A1b560c66525E64A1c9B6a04"): "0x3635C9ADC5DEA00000",   // 1000 CHZ
-	},
-	VotingPeriod: 1200, // (~1hour)
-	// faucet
-	Faucet: map[common.Address]string{
-		common.HexToAddress("0x77c6DC8fC511Bf2Fa594c47DdC336C69D745e73A"): "0x197D7361310E45C669F80000", // main
-		common.HexToAddress("0xa6779032c48127f362244AADD80E3A6E1b50BA93"): "0x33B2E3C9FD0803CE8000000",  // faucet
-	},
-	Forks: ChilizForks{
-		RuntimeUpgradeBlock:    (*math.HexOrDecimal256)(big.NewInt(0)),
-		DeployOriginBlock:      (*math.HexOrDecimal256)(big.NewInt(0)),
-		DeploymentHookFixBlock: (*math.HexOrDecimal256)(big.NewInt(0)),
-		DeployerFactoryBlock:   nil,
-	},
+var builtinNetworks = []builtinNetwork{
+	{Name: "localnet", Title: "localnet", Config: localNetConfig, GenesisFile: "localnet.json"},
+	{Name: "devnet", Title: "devnet", Config: devNetConfig, GenesisFile: "devnet.json"},
+	{Name: "testnet", Title: "scoville testnet", Config: testNetConfig, GenesisFile: "testnet.json", UpdateOnlyConfig: true},
+	{Name: "spicy", Title: "spicy testnet", Config: spicyConfig, GenesisFile: "spicy.json", UpdateOnlyConfig: true},
+	{Name: "mainnet", Title: "mainnet", Config: mainNetConfig, GenesisFile: "mainnet.json", UpdateOnlyConfig: true},
 }
 
-var mainNetConfig = genesisConfig{
-	ChainId: 88888,
-	// who is able to deploy smart contract from genesis block (it won't generate event log)
-	Deployers: []common.Address{
-		common.HexToAddress("0xfe74A701E42670fc23b64f8C4FaC59a0A01e6aA3"),
-	},
-	// list of default validators (it won't generate event log)
-	Validators: []common.Address{
-		common.HexToAddress("0x2045A60c9BFFCCEEB5a1AAD0e22A75965d221882"),
-		common.HexToAddress("0x811ceF18Ac8b28e0c4A54aB8220a51897ba9C489"),
-		common.HexToAddress("0x4d466f3A688Cb1096497dbcB9Fd68E500e24f0B1"),
-		common.HexToAddress("0x5c12a44A0bbaaF133123895cf90e05d94D6137Dc"),
-		common.HexToAddress("0x64552Cb88DE4Cd7438bFc6b8d4757305C6FA96Ae"),
-		common.HexToAddress("0xE548F293E2BA625eFB34c11e43217dD4330D6da8"),
-		common.HexToAddress("0xA2ec78Eb13C40c03F3F9283f7057B6C7E652F644"),
-		common.HexToAddress("0x7486B4f8f036B4Df55f7a55ab9b61D6d605067c6"),
-		common.HexToAddress("0xf57c7a5BCB023aB18683A46fA25a00fB19d651bE"),
-		common.HexToAddress("0xE0efCc3Fb5B1c66257945Ebc533C101783Fe97b4"),
-		common.HexToAddress("0x39a7179B6c73622B63B8b58b973835e00E9d38b4"),
-		common.HexToAddress("0x2064F56684377A8C50F4CdfBD5C65873763143fb"),
-		common.HexToAddress("0xe5cFf8f16dA0b3067BC7432ba2b4AE7199EAAE53"),
-		common.HexToAddress("0x52527E4b47ad69Cd69021fBB6dA2A4F210FEec62"),
-		common.HexToAddress("0x31Dd5A7429ae591D2d73935C001DD148faBDd2cf"),
-	},
-	SystemTreasury: map[common.Address]uint16{
-		common.HexToAddress("0xFddAc11E0072e3377775345D58de0dc88A964837"): 10000,
-	},
-	ConsensusParams: consensusParams{
-		ActiveValidatorsLength:   11,
-		EpochBlockInterval:       28800,                                                                     // 1 day
-		MisdemeanorThreshold:     14400,                                                                     // missed blocks per epoch
-		FelonyThreshold:          21600,                                                                     // missed blocks per epoch
-		ValidatorJailEpochLength: 7,                                                                         // nb of epochs
-		UndelegatePeriod:         7,                                                                         // nb of epochs
-		MinValidatorStakeAmount:  (*math.HexOrDecimal256)(hexutil.MustDecodeBig("0x84595161401484A000000")), // how many tokens validator must stake to create a validator (in ether) - 10,000,000
-		MinStakingAmount:         (*math.HexOrDecimal256)(hexutil.MustDecodeBig("0x56BC75E2D63100000")),     // minimum staking amount for delegators (in CHZ) - 100
-	},
-	VotingPeriod: 271600, // 7 days
-	InitialStakes: map[common.Address]string{
-		common.HexToAddress("0x2045A60c9BFFCCEEB5a1AAD0e22A75965d221882"): "0x84595161401484A000000", // Validator 10,000,000 CHZ
-		common.HexToAddress("0x811ceF18Ac8b28e0c4A54aB8220a51897ba9C489"): "0x84595161401484A000000", // Validator 10,000,000 CHZ
-		common.HexToAddress("0x4d466f3A688Cb1096497dbcB9Fd68E500e24f0B1"): "0x84595161401484A000000", // Validator 10,000,000 CHZ
-		common.HexToAddress("0x5c12a44A0bbaaF133123895cf90e05d94D6137Dc"): "0x84595161401484A000000", // Validator 10,000,000 CHZ
-		common.HexToAddress("0x64552Cb88DE4Cd7438bFc6b8d4757305C6FA96Ae"): "0x84595161401484A000000", // Validator 10,000,000 CHZ
-		common.HexToAddress("0xE548F293E2BA625eFB34c11e43217dD4330D6da8"): "0x84595161401484A000000", // Validator 10,000,000 CHZ
-		common.HexToAddress("0xA2ec78Eb13C40c03F3F9283f7057B6C7E652F644"): "0x84595161401484A000000", // Validator 10,000,000 CHZ
-		common.HexToAddress("0x7486B4f8f036B4Df55f7a55ab9b61D6d605067c6"): "0x84595161401484A000000", // Validator 10,000,000 CHZ
-		common.HexToAddress("0xf57c7a5BCB023aB18683A46fA25a00fB19d651bE"): "0x84595161401484A000000", // Validator 10,000,000 CHZ
-		common.HexToAddress("0xE0efCc3Fb5B1c66257945Ebc533C101783Fe97b4"): "0x84595161401484A000000", // Validator 10,000,000 CHZ
-		common.HexToAddress("0x39a7179B6c73622B63B8b58b973835e00E9d38b4"): "0x84595161401484A000000", // Validator 10,000,000 CHZ
-		common.HexToAddress("0x2064F56684377A8C50F4CdfBD5C65873763143fb"): "0x84595161401484A000000", // Validator 10,000,000 CHZ
-		common.HexToAddress("0xe5cFf8f16dA0b3067BC7432ba2b4AE7199EAAE53"): "0x84595161401484A000000", // Validator 10,000,000 CHZ
-		common.HexToAddress("0x52527E4b47ad69Cd69021fBB6dA2A4F210FEec62"): "0x84595161401484A000000", // Validator 10,000,000 CHZ
-		common.HexToAddress("0x31Dd5A7429ae591D2d73935C001DD148faBDd2cf"): "0x84595161401484A000000", // Validator 10,000,000 CHZ
-	},
-	// Supply Distribution
-	Faucet: map[common.Address]string{
-		common.HexToAddress("0xFddAc11E0072e3377775345D58de0dc88A964837"): "0x1C3CA1E1AAC1A93AF8800000", // Treasury 8,738,880,288 eth
-		common.HexToAddress("0xfe74A701E42670fc23b64f8C4FaC59a0A01e6aA3"): "0x56BC75E2D63100000",        // Deployer 100 CHZ
-		common.HexToAddress("0x8ee1c1f4b14c0A1698BdA02f58021968010523D2"): "0x56BC75E2D63100000",        // Validator owner 100 CHZ
-		common.HexToAddress("0xf9768B0Ac91F4B27f7F4DC88574a050c0e13Ccc1"): "0x56BC75E2D63100000",        // Validator owner 100 CHZ
-		common.HexToAddress("0x97ADd7226B3f1020fB3308cc67e74cb77757C211"): "0x56BC75E2D63100000",        // Validator owner 100 CHZ
-		common.HexToAddress("0x72676b2A2371Af4Fe23515e0E8bE9d44Bf41A6f4"): "0x56BC75E2D63100000",        // Validator owner 100 CHZ
-		common.HexToAddress("0xb67D0e9394932d3cFa6102A55F636481FBcc7976"): "0x56BC75E2D63100000",        // Validator owner 100 CHZ
-		common.HexToAddress("0x92D00DA3aE5f01761f5e1f425AFe3322931AAd31"): "0x56BC75E2D63100000",        // Validator owner 100 CHZ
-		common.HexToAddress("0xF25E764a2222532008D89FC018E70c18DD2401C2"): "0x56BC75E2D63100000",        // Validator owner 100 CHZ
-		common.HexToAddress("0x4e4620FE9dF2751F55FA01D24413343290c22698"): "0x56BC75E2D63100000",        // Validator owner 100 CHZ
-		common.HexToAddress("0xf299AfC34ec0B9dCAF868914288d735149d6306f"): "0x56BC75E2D63100000",        // Validator owner 100 CHZ
-		common.HexToAddress("0x9a905C99D7753F01918E389C785b5862CF7A3945"): "0x56BC75E2D63100000",        // Validator owner 100 CHZ
-		common.HexToAddress("0x19d0bc6d0Ca394E3547fF06A0F2805dB623dEcA8"): "0x56BC75E2D63100000",        // Validator owner 100 CHZ
-		common.HexToAddress("0x7F420438941EB35bCe2E7C6824B8f9c04Ad4f188"): "0x56BC75E2D63100000",        // Validator owner 100 CHZ
-		common.HexToAddress("0xdC3A7153A2afB491B94784d86d6A915Ce5dde102"): "0x56BC75E2D63100000",        // Validator owner 100 CHZ
-		common.HexToAddress("0x8a999c490793f9d340Be71Ea4Ae81E9C627bD0cd"): "0x56BC75E2D63100000",        // Validator owner 100 CHZ
-		common.HexToAddress("0x6d25F93FAb44a7651dd52B3560ac74d98e1f912C"): "0x56BC75E2D63100000",        // Validator owner 100 CHZ
-		common.HexToAddress("0x4b045692540E6B7AfDE44cdad60136d170efc623"): "0x3635C9ADC5DEA00000",       // Bridge relayer 1,000 CHZ
-		common.HexToAddress("0xb0AdF650ABDc7d2d5ac7366888ab492e9Df8589A"): "0x3635C9ADC5DEA00000",       // Bridge relayer 1,000 CHZ
-		common.HexToAddress("0x52f30AefB50B5d271d93A10730088733Bdbe31E0"): "0x3635C9ADC5DEA00000",       // Bridge relayer 1,000 CHZ
-		common.HexToAddress("0x1Cb83A71d81DaCe297975e377777c94a32d9D5dD"): "0x3635C9ADC5DEA00000",       // Bridge relayer 1,000 CHZ
-		common.HexToAddress("0xAE68F408160C40d508834734aC5bEd773a36e9D2"): "0x3635C9ADC5DEA00000",       // Bridge relayer 1,000 CHZ
-		common.HexToAddress("0x3665dfcdaf8310684c24592b017D986A993320e6"): "0x3635C9ADC5DEA00000",       // Bridge relayer 1,000 CHZ
-		common.HexToAddress("0x252B5CA6c838ae47508c1eA72Dd73b58c607Af0f"): "0x3635C9ADC5DEA00000",       // Bridge relayer 1,000 CHZ
-	},
-	Forks: ChilizForks{
-		RuntimeUpgradeBlock:    (*math.HexOrDecimal256)(big.NewInt(0)),
-		DeployOriginBlock:      (*math.HexOrDecimal256)(big.NewInt(0)),
-		DeploymentHookFixBlock: (*math.HexOrDecimal256)(big.NewInt(0)),
-		DeployerFactoryBlock:   nil, // TODO: "specify fork block here"
-	},
+func builtinNetworkByName(name string) (builtinNetwork, bool) {
+	for _, n := range builtinNetworks {
+		if n.Name == name {
+			return n, true
+		}
+	}
+	return builtinNetwork{}, false
+}
+
+// readGenesisConfigFile reads the config merged with its base configs, environment variables are substituted,
+// unknown fields and wrong address checksums are rejected
+func readGenesisConfigFile(configFile string) (*genesisConfig, error) {
+	return readGenesisConfig(os.ReadFile, configFile)
+}
+
+func readGenesisConfig(read configReader, configFile string) (*genesisConfig, error) {
+	resolved, err := resolveConfig(read, configFile)
+	if err != nil {
+		return nil, err
+	}
+	config, err := decodeGenesisConfig(resolved.Config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config (%s): %w", configFile, err)
+	}
+	return config, nil
+}
+
+// commands are invoked as "create-genesis <command> [flags]", everything else is treated as a config file
+var commands = map[string]func(args []string) error{
+	"upgrade-proposal":    runUpgradeProposalCommand,
+	"storage-layout":      runStorageLayoutCommand,
+	"simulate-upgrade":    runSimulateUpgradeCommand,
+	"import-state":        runImportStateCommand,
+	"simulate-governance": runSimulateGovernanceCommand,
+	"project-supply":      runProjectSupplyCommand,
+	"build":               runBuildCommand,
+	"import-alloc":        runImportAllocCommand,
+	"bls-keygen":          runBlsKeygenCommand,
+	"manifest":            runManifestCommand,
+	"bindings":            runBindingsCommand,
+	"sign-tx":             runSignTxCommand,
+	"keystore-audit":      runKeystoreAuditCommand,
+	"resolve-config":      runResolveConfigCommand,
+	"config-schema":       runConfigSchemaCommand,
+	"inspect":             runInspectCommand,
+	"verify":              runVerifyCommand,
+	"register-release":    runRegisterReleaseCommand,
+	"bytecode-diff":       runBytecodeDiffCommand,
+	"benchmark-build":     runBenchmarkBuildCommand,
 }
 
 func main() {
 	args := os.Args[1:]
 	if len(args) > 0 {
-		fileContents, err := os.ReadFile(args[0])
-		if err != nil {
-			panic(err)
+		if command, ok := commands[args[0]]; ok {
+			if err := command(args[1:]); err != nil {
+				fmt.Fprintf(os.Stderr, "ERR: %v\n", err)
+				os.Exit(1)
+			}
+			return
 		}
-		genesis := &genesisConfig{}
-		err = json.Unmarshal(fileContents, genesis)
+	}
+	if len(args) > 0 {
+		genesis, err := readGenesisConfigFile(args[0])
 		if err != nil {
 			panic(err)
 		}
@@ -760,31 +979,29 @@ func main() {
 		if len(args) > 1 {
 			outputFile = args[1]
 		}
-		err = createGenesisConfig(*genesis, outputFile, false)
+		_, err = createGenesisConfig(*genesis, outputFile, false)
 		if err != nil {
 			panic(err)
 		}
 		return
 	}
-	fmt.Printf("building localnet\n")
-	if err := createGenesisConfig(localNetConfig, "localnet.json", false); err != nil {
-		panic(err)
-	}
-	fmt.Printf("\nbuilding devnet\n")
-	if err := createGenesisConfig(devNetConfig, "devnet.json", false); err != nil {
-		panic(err)
-	}
-	fmt.Printf("\nbuilding scoville testnet\n")
-	if err := createGenesisConfig(testNetConfig, "testnet.json", true); err != nil {
-		panic(err)
+	builds, err := buildNetworks(builtinNetworks)
+	for i, build := range builds {
+		if i > 0 {
+			fmt.Printf("\n")
+		}
+		os.Stdout.Write(build.Log.Bytes())
 	}
-	fmt.Printf("\nbuilding spicy testnet\n")
-	if err := createGenesisConfig(spicyConfig, "spicy.json", true); err != nil {
-		panic(err)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERR: %v\n", err)
+		os.Exit(1)
 	}
-	fmt.Printf("\nbuilding mainnet\n")
-	if err := createGenesisConfig(mainNetConfig, "mainnet.json", true); err != nil {
-		panic(err)
+	// files are written only if all networks are built, genesis files of launched networks are read by the builds
+	for i, network := range builtinNetworks {
+		newJson, _ := json.MarshalIndent(builds[i].Genesis, "", "  ")
+		if err := writeOutputFile(network.GenesisFile, newJson); err != nil {
+			panic(err)
+		}
 	}
 	fmt.Printf("\n")
 }
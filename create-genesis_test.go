@@ -0,0 +1,315 @@
+package main
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+)
+
+// configs of built-in networks as they were written in Go before moving to configs/networks,
+// overlays must resolve to the same configs, otherwise genesis of launched networks changes
+
+var expectedDevNetConfig = genesisConfig{
+	ChainId: 17243,
+	// who is able to deploy smart contract from genesis block (it won't generate event log)
+	Deployers: []common.Address{},
+	// list of default validators (it won't generate event log)
+	Validators: []common.Address{
+		common.HexToAddress("0x08fae3885e299c24ff9841478eb946f41023ac69"),
+		common.HexToAddress("0x49c0f7c8c11a4c80dc6449efe1010bb166818da8"),
+		common.HexToAddress("0x751aaca849b09a3e347bbfe125cf18423cc24b40"),
+		common.HexToAddress("0x8e1ea6eaa09c3b40f4a51fcd056a031870a0549a"),
+		common.HexToAddress("0xa6ff33e3250cc765052ac9d7f7dfebda183c4b9b"),
+	},
+	// genesis is regenerated on every build, so validators must be in canonical order
+	ValidatorOrdering: validatorOrderingStrict,
+	SystemTreasury: map[common.Address]uint16{
+		common.HexToAddress("0x0000000000000000000000000000000000000000"): 10000,
+	},
+	ConsensusParams: consensusParams{
+		ActiveValidatorsLength:   25,   // suggested values are (3k+1, where k is honest validators, even better): 7, 13, 19, 25, 31...
+		EpochBlockInterval:       1200, // better to use 1 day epoch (86400/3=28800, where 3s is block time)
+		MisdemeanorThreshold:     50,   // after missing this amount of blocks per day validator losses all daily rewards (penalty)
+		FelonyThreshold:          150,  // after missing this amount of blocks per day validator goes in jail for N epochs
+		ValidatorJailEpochLength: 7,    // how many epochs validator should stay in jail (7 epochs = ~7 days)
+		UndelegatePeriod:         6,    // allow claiming funds only after 6 epochs (~7 days)
+
+		MinValidatorStakeAmount: (*math.HexOrDecimal256)(hexutil.MustDecodeBig("0xde0b6b3a7640000")), // how many tokens validator must stake to create a validator (in ether)
+		MinStakingAmount:        (*math.HexOrDecimal256)(hexutil.MustDecodeBig("0xde0b6b3a7640000")), // minimum staking amount for delegators (in ether)
+	},
+	InitialStakes: map[common.Address]string{
+		common.HexToAddress("0x08fae3885e299c24ff9841478eb946f41023ac69"): "0x3635c9adc5dea00000", // 1000 eth
+		common.HexToAddress("0x751aaca849b09a3e347bbfe125cf18423cc24b40"): "0x3635c9adc5dea00000", // 1000 eth
+		common.HexToAddress("0xa6ff33e3250cc765052ac9d7f7dfebda183c4b9b"): "0x3635c9adc5dea00000", // 1000 eth
+		common.HexToAddress("0x49c0f7c8c11a4c80dc6449efe1010bb166818da8"): "0x3635c9adc5dea00000", // 1000 eth
+		common.HexToAddress("0x8e1ea6eaa09c3b40f4a51fcd056a031870a0549a"): "0x3635c9adc5dea00000", // 1000 eth
+	},
+	// owner of the governance
+	VotingPeriod: 60, // 3 minutes
+	// faucet
+	Faucet: map[common.Address]string{
+		common.HexToAddress("0x00a601f45688dba8a070722073b015277cf36725"): "0x21e19e0c9bab2400000",    // governance
+		common.HexToAddress("0xb891fe7b38f857f53a7b5529204c58d5c487280b"): "0x52b7d2dcc80cd2e4000000", // faucet (10kk)
+	},
+}
+
+var expectedTestNetConfig = genesisConfig{
+	ChainId: 88880,
+	// who is able to deploy smart contract from genesis block (it won't generate event log)
+	Deployers: []common.Address{
+		common.HexToAddress("0x54E98ee51446505fcf69093E015Ee36034321104"),
+	},
+	// list of default validators (it won't generate event log)
+	Validators: []common.Address{
+		common.HexToAddress("0x86d12897C56Fe1dB08BDfB84Bc90f458ee7dC5cE"),
+		common.HexToAddress("0xE45D81a7EF9456A254aa4db010AAF6601a15B5B7"),
+		common.HexToAddress("0x76106F0857938684D24f2CE167EE11607dFaa57d"),
+		common.HexToAddress("0x48223C151df5dc1dBc2E24f17e77728358113705"),
+		common.HexToAddress("0x49CfDafF386FD2683d28678aBd53F11Dec23c76C"),
+	},
+	// network is launched already, genesis hash depends on the validators order
+	ValidatorOrdering: validatorOrderingLegacy,
+	SystemTreasury: map[common.Address]uint16{
+		common.HexToAddress("0xde8712be934a6A4C7dDd17DC91669F51284f4b0c"): 10000,
+	},
+	ConsensusParams: consensusParams{
+		ActiveValidatorsLength:   5,
+		EpochBlockInterval:       1200,                                                                   // (~1hour)
+		MisdemeanorThreshold:     100,                                                                    // missed blocks per epoch
+		FelonyThreshold:          200,                                                                    // missed blocks per epoch
+		ValidatorJailEpochLength: 6,                                                                      // nb of epochs
+		UndelegatePeriod:         1,                                                                      // nb of epochs
+		MinValidatorStakeAmount:  (*math.HexOrDecimal256)(hexutil.MustDecodeBig("0x3635c9adc5dea00000")), // how many tokens validator must stake to create a validator (in ether)
+		MinStakingAmount:         (*math.HexOrDecimal256)(hexutil.MustDecodeBig("0xde0b6b3a7640000")),    // minimum staking amount for delegators (in ether)
+	},
+	InitialStakes: map[common.Address]string{
+		common.HexToAddress("0x86d12897C56Fe1dB08BDfB84Bc90f458ee7dC5cE"): "0x152D02C7E14AF6800000", // 100 000 eth
+		common.HexToAddress("0xE45D81a7EF9456A254aa4db010AAF6601a15B5B7"): "0x3635C9ADC5DEA00000",   // 1000 eth
+		common.HexToAddress("0x76106F0857938684D24f2CE167EE11607dFaa57d"): "0x3635C9ADC5DEA00000",   // 1000 eth
+		common.HexToAddress("0x48223C151df5dc1dBc2E24f17e77728358113705"): "0x3635C9ADC5DEA00000",   // 1000 eth
+		common.HexToAddress("0x49CfDafF386FD2683d28678aBd53F11Dec23c76C"): "0x2B5E3AF16B1880000",    // 50 eth
+	},
+	// owner of the governance
+	VotingPeriod: 1200, // (~1hour)
+	// faucet
+	Faucet: map[common.Address]string{
+		common.HexToAddress("0xb0c09bF51E04eDc7Bf198D61bB74CDa886878167"): "0x197D7361310E45C669F80000", // main
+		common.HexToAddress("0xc59181b702A7F3A8eCea27f30072B8dbCcC0c48a"): "0x33B2E3C9FD0803CE8000000",  // faucet
+	},
+	Forks: ChilizForks{
+		RuntimeUpgradeBlock:    (*math.HexOrDecimal256)(big.NewInt(0)),
+		DeployOriginBlock:      (*math.HexOrDecimal256)(big.NewInt(2849000)),
+		DeploymentHookFixBlock: (*math.HexOrDecimal256)(big.NewInt(6067300)),
+		DeployerFactoryBlock:   nil,
+	},
+}
+
+var expectedSpicyConfig = genesisConfig{
+	ChainId: 88882,
+	// who is able to deploy smart contract from genesis block (it won't generate event log)
+	Deployers: []common.Address{
+		common.HexToAddress("0x02880217b082cC24D371eB5Bad0827D208bcBC6D"),
+	},
+	// list of default validators (it won't generate event log)
+	Validators: []common.Address{
+		common.HexToAddress("0xb1b5a8b8E2a263C0F497BC32a7cb6D27AEA921fc"),
+		common.HexToAddress("0x4dD74707f22b74EC872CA6AEB2a065E3d006B9d9"),
+		common.HexToAddress("0xBD6D190548bbF5C6920a826dF063A970Bd18f307"),
+		common.HexToAddress("0xeC2e502f77c4811f2ef477397235976b1371FCd3"),
+		common.HexToAddress("0x1cB3FC9e10fB5b845e53e5EaAE0bD561e662b0A5"),
+		common.HexToAddress("0xbdBF08393b66130B4b243863150A265b2A5Df642"),
+		common.HexToAddress("0x86f2BB174c450917A1b560c66525E64A1c9B6a04"),
+	},
+	// network is launched already, genesis hash depends on the validators order
+	ValidatorOrdering: validatorOrderingLegacy,
+	SystemTreasury: map[common.Address]uint16{
+		common.HexToAddress("0x060eA461Cf7E78A38400dE9255687beb9b2c7298"): 10000,
+	},
+	ConsensusParams: consensusParams{
+		ActiveValidatorsLength:   5,
+		EpochBlockInterval:       7200,                                                                   // ~6 hours
+		MisdemeanorThreshold:     400,                                                                    // missed blocks per epoch
+		FelonyThreshold:          800,                                                                    // missed blocks per epoch
+		ValidatorJailEpochLength: 4,                                                                      // nb of epochs
+		UndelegatePeriod:         1,                                                                      // nb of epochs
+		MinValidatorStakeAmount:  (*math.HexOrDecimal256)(hexutil.MustDecodeBig("0x3635c9adc5dea00000")), // how many tokens validator must stake to create a validator (in ether)
+		MinStakingAmount:         (*math.HexOrDecimal256)(hexutil.MustDecodeBig("0xde0b6b3a7640000")),    // minimum staking amount for delegators (in ether)
+	},
+	InitialStakes: map[common.Address]string{
+		common.HexToAddress("0xb1b5a8b8E2a263C0F497BC32a7cb6D27AEA921fc"): "0x152D02C7E14AF6800000", // 100 000 CHZ
+		common.HexToAddress("0x4dD74707f22b74EC872CA6AEB2a065E3d006B9d9"): "0x3635C9ADC5DEA00000",   // 1000 CHZ
+		common.HexToAddress("0xBD6D190548bbF5C6920a826dF063A970Bd18f307"): "0x3635C9ADC5DEA00000",   // 1000 CHZ
+		common.HexToAddress("0xeC2e502f77c4811f2ef477397235976b1371FCd3"): "0x3635C9ADC5DEA00000",   // 1000 CHZ
+		common.HexToAddress("0x1cB3FC9e10fB5b845e53e5EaAE0bD561e662b0A5"): "0x3635C9ADC5DEA00000",   // 1000 CHZ
+		common.HexToAddress("0xbdBF08393b66130B4b243863150A265b2A5Df642"): "0x3635C9ADC5DEA00000",   // 1000 CHZ
+		common.HexToAddress("0x86f2BB174c450917A1b560c66525E64A1c9B6a04"): "0x3635C9ADC5DEA00000",   // 1000 CHZ
+	},
+	VotingPeriod: 1200, // (~1hour)
+	// faucet
+	Faucet: map[common.Address]string{
+		common.HexToAddress("0x77c6DC8fC511Bf2Fa594c47DdC336C69D745e73A"): "0x197D7361310E45C669F80000", // main
+		common.HexToAddress("0xa6779032c48127f362244AADD80E3A6E1b50BA93"): "0x33B2E3C9FD0803CE8000000",  // faucet
+	},
+	Forks: ChilizForks{
+		RuntimeUpgradeBlock:    (*math.HexOrDecimal256)(big.NewInt(0)),
+		DeployOriginBlock:      (*math.HexOrDecimal256)(big.NewInt(0)),
+		DeploymentHookFixBlock: (*math.HexOrDecimal256)(big.NewInt(0)),
+		DeployerFactoryBlock:   nil,
+	},
+}
+
+var expectedMainNetConfig = genesisConfig{
+	ChainId: 88888,
+	// who is able to deploy smart contract from genesis block (it won't generate event log)
+	Deployers: []common.Address{
+		common.HexToAddress("0xfe74A701E42670fc23b64f8C4FaC59a0A01e6aA3"),
+	},
+	// list of default validators (it won't generate event log)
+	Validators: []common.Address{
+		common.HexToAddress("0x2045A60c9BFFCCEEB5a1AAD0e22A75965d221882"),
+		common.HexToAddress("0x811ceF18Ac8b28e0c4A54aB8220a51897ba9C489"),
+		common.HexToAddress("0x4d466f3A688Cb1096497dbcB9Fd68E500e24f0B1"),
+		common.HexToAddress("0x5c12a44A0bbaaF133123895cf90e05d94D6137Dc"),
+		common.HexToAddress("0x64552Cb88DE4Cd7438bFc6b8d4757305C6FA96Ae"),
+		common.HexToAddress("0xE548F293E2BA625eFB34c11e43217dD4330D6da8"),
+		common.HexToAddress("0xA2ec78Eb13C40c03F3F9283f7057B6C7E652F644"),
+		common.HexToAddress("0x7486B4f8f036B4Df55f7a55ab9b61D6d605067c6"),
+		common.HexToAddress("0xf57c7a5BCB023aB18683A46fA25a00fB19d651bE"),
+		common.HexToAddress("0xE0efCc3Fb5B1c66257945Ebc533C101783Fe97b4"),
+		common.HexToAddress("0x39a7179B6c73622B63B8b58b973835e00E9d38b4"),
+		common.HexToAddress("0x2064F56684377A8C50F4CdfBD5C65873763143fb"),
+		common.HexToAddress("0xe5cFf8f16dA0b3067BC7432ba2b4AE7199EAAE53"),
+		common.HexToAddress("0x52527E4b47ad69Cd69021fBB6dA2A4F210FEec62"),
+		common.HexToAddress("0x31Dd5A7429ae591D2d73935C001DD148faBDd2cf"),
+	},
+	// network is launched already, genesis hash depends on the validators order
+	ValidatorOrdering: validatorOrderingLegacy,
+	SystemTreasury: map[common.Address]uint16{
+		common.HexToAddress("0xFddAc11E0072e3377775345D58de0dc88A964837"): 10000,
+	},
+	ConsensusParams: consensusParams{
+		ActiveValidatorsLength:   11,
+		EpochBlockInterval:       28800,                                                                     // 1 day
+		MisdemeanorThreshold:     14400,                                                                     // missed blocks per epoch
+		FelonyThreshold:          21600,                                                                     // missed blocks per epoch
+		ValidatorJailEpochLength: 7,                                                                         // nb of epochs
+		UndelegatePeriod:         7,                                                                         // nb of epochs
+		MinValidatorStakeAmount:  (*math.HexOrDecimal256)(hexutil.MustDecodeBig("0x84595161401484A000000")), // how many tokens validator must stake to create a validator (in ether) - 10,000,000
+		MinStakingAmount:         (*math.HexOrDecimal256)(hexutil.MustDecodeBig("0x56BC75E2D63100000")),     // minimum staking amount for delegators (in CHZ) - 100
+	},
+	VotingPeriod: 271600, // 7 days
+	InitialStakes: map[common.Address]string{
+		common.HexToAddress("0x2045A60c9BFFCCEEB5a1AAD0e22A75965d221882"): "0x84595161401484A000000", // Validator 10,000,000 CHZ
+		common.HexToAddress("0x811ceF18Ac8b28e0c4A54aB8220a51897ba9C489"): "0x84595161401484A000000", // Validator 10,000,000 CHZ
+		common.HexToAddress("0x4d466f3A688Cb1096497dbcB9Fd68E500e24f0B1"): "0x84595161401484A000000", // Validator 10,000,000 CHZ
+		common.HexToAddress("0x5c12a44A0bbaaF133123895cf90e05d94D6137Dc"): "0x84595161401484A000000", // Validator 10,000,000 CHZ
+		common.HexToAddress("0x64552Cb88DE4Cd7438bFc6b8d4757305C6FA96Ae"): "0x84595161401484A000000", // Validator 10,000,000 CHZ
+		common.HexToAddress("0xE548F293E2BA625eFB34c11e43217dD4330D6da8"): "0x84595161401484A000000", // Validator 10,000,000 CHZ
+		common.HexToAddress("0xA2ec78Eb13C40c03F3F9283f7057B6C7E652F644"): "0x84595161401484A000000", // Validator 10,000,000 CHZ
+		common.HexToAddress("0x7486B4f8f036B4Df55f7a55ab9b61D6d605067c6"): "0x84595161401484A000000", // Validator 10,000,000 CHZ
+		common.HexToAddress("0xf57c7a5BCB023aB18683A46fA25a00fB19d651bE"): "0x84595161401484A000000", // Validator 10,000,000 CHZ
+		common.HexToAddress("0xE0efCc3Fb5B1c66257945Ebc533C101783Fe97b4"): "0x84595161401484A000000", // Validator 10,000,000 CHZ
+		common.HexToAddress("0x39a7179B6c73622B63B8b58b973835e00E9d38b4"): "0x84595161401484A000000", // Validator 10,000,000 CHZ
+		common.HexToAddress("0x2064F56684377A8C50F4CdfBD5C65873763143fb"): "0x84595161401484A000000", // Validator 10,000,000 CHZ
+		common.HexToAddress("0xe5cFf8f16dA0b3067BC7432ba2b4AE7199EAAE53"): "0x84595161401484A000000", // Validator 10,000,000 CHZ
+		common.HexToAddress("0x52527E4b47ad69Cd69021fBB6dA2A4F210FEec62"): "0x84595161401484A000000", // Validator 10,000,000 CHZ
+		common.HexToAddress("0x31Dd5A7429ae591D2d73935C001DD148faBDd2cf"): "0x84595161401484A000000", // Validator 10,000,000 CHZ
+	},
+	// Supply Distribution
+	Faucet: map[common.Address]string{
+		common.HexToAddress("0xFddAc11E0072e3377775345D58de0dc88A964837"): "0x1C3CA1E1AAC1A93AF8800000", // Treasury 8,738,880,288 eth
+		common.HexToAddress("0xfe74A701E42670fc23b64f8C4FaC59a0A01e6aA3"): "0x56BC75E2D63100000",        // Deployer 100 CHZ
+		common.HexToAddress("0x8ee1c1f4b14c0A1698BdA02f58021968010523D2"): "0x56BC75E2D63100000",        // Validator owner 100 CHZ
+		common.HexToAddress("0xf9768B0Ac91F4B27f7F4DC88574a050c0e13Ccc1"): "0x56BC75E2D63100000",        // Validator owner 100 CHZ
+		common.HexToAddress("0x97ADd7226B3f1020fB3308cc67e74cb77757C211"): "0x56BC75E2D63100000",        // Validator owner 100 CHZ
+		common.HexToAddress("0x72676b2A2371Af4Fe23515e0E8bE9d44Bf41A6f4"): "0x56BC75E2D63100000",        // Validator owner 100 CHZ
+		common.HexToAddress("0xb67D0e9394932d3cFa6102A55F636481FBcc7976"): "0x56BC75E2D63100000",        // Validator owner 100 CHZ
+		common.HexToAddress("0x92D00DA3aE5f01761f5e1f425AFe3322931AAd31"): "0x56BC75E2D63100000",        // Validator owner 100 CHZ
+		common.HexToAddress("0xF25E764a2222532008D89FC018E70c18DD2401C2"): "0x56BC75E2D63100000",        // Validator owner 100 CHZ
+		common.HexToAddress("0x4e4620FE9dF2751F55FA01D24413343290c22698"): "0x56BC75E2D63100000",        // Validator owner 100 CHZ
+		common.HexToAddress("0xf299AfC34ec0B9dCAF868914288d735149d6306f"): "0x56BC75E2D63100000",        // Validator owner 100 CHZ
+		common.HexToAddress("0x9a905C99D7753F01918E389C785b5862CF7A3945"): "0x56BC75E2D63100000",        // Validator owner 100 CHZ
+		common.HexToAddress("0x19d0bc6d0Ca394E3547fF06A0F2805dB623dEcA8"): "0x56BC75E2D63100000",        // Validator owner 100 CHZ
+		common.HexToAddress("0x7F420438941EB35bCe2E7C6824B8f9c04Ad4f188"): "0x56BC75E2D63100000",        // Validator owner 100 CHZ
+		common.HexToAddress("0xdC3A7153A2afB491B94784d86d6A915Ce5dde102"): "0x56BC75E2D63100000",        // Validator owner 100 CHZ
+		common.HexToAddress("0x8a999c490793f9d340Be71Ea4Ae81E9C627bD0cd"): "0x56BC75E2D63100000",        // Validator owner 100 CHZ
+		common.HexToAddress("0x6d25F93FAb44a7651dd52B3560ac74d98e1f912C"): "0x56BC75E2D63100000",        // Validator owner 100 CHZ
+		common.HexToAddress("0x4b045692540E6B7AfDE44cdad60136d170efc623"): "0x3635C9ADC5DEA00000",       // Bridge relayer 1,000 CHZ
+		common.HexToAddress("0xb0AdF650ABDc7d2d5ac7366888ab492e9Df8589A"): "0x3635C9ADC5DEA00000",       // Bridge relayer 1,000 CHZ
+		common.HexToAddress("0x52f30AefB50B5d271d93A10730088733Bdbe31E0"): "0x3635C9ADC5DEA00000",       // Bridge relayer 1,000 CHZ
+		common.HexToAddress("0x1Cb83A71d81DaCe297975e377777c94a32d9D5dD"): "0x3635C9ADC5DEA00000",       // Bridge relayer 1,000 CHZ
+		common.HexToAddress("0xAE68F408160C40d508834734aC5bEd773a36e9D2"): "0x3635C9ADC5DEA00000",       // Bridge relayer 1,000 CHZ
+		common.HexToAddress("0x3665dfcdaf8310684c24592b017D986A993320e6"): "0x3635C9ADC5DEA00000",       // Bridge relayer 1,000 CHZ
+		common.HexToAddress("0x252B5CA6c838ae47508c1eA72Dd73b58c607Af0f"): "0x3635C9ADC5DEA00000",       // Bridge relayer 1,000 CHZ
+	},
+	FaucetLabels: map[common.Address]string{
+		common.HexToAddress("0xFddAc11E0072e3377775345D58de0dc88A964837"): "Treasury",
+		common.HexToAddress("0xfe74A701E42670fc23b64f8C4FaC59a0A01e6aA3"): "Deployer",
+		common.HexToAddress("0x8ee1c1f4b14c0A1698BdA02f58021968010523D2"): "Validator owner",
+		common.HexToAddress("0xf9768B0Ac91F4B27f7F4DC88574a050c0e13Ccc1"): "Validator owner",
+		common.HexToAddress("0x97ADd7226B3f1020fB3308cc67e74cb77757C211"): "Validator owner",
+		common.HexToAddress("0x72676b2A2371Af4Fe23515e0E8bE9d44Bf41A6f4"): "Validator owner",
+		common.HexToAddress("0xb67D0e9394932d3cFa6102A55F636481FBcc7976"): "Validator owner",
+		common.HexToAddress("0x92D00DA3aE5f01761f5e1f425AFe3322931AAd31"): "Validator owner",
+		common.HexToAddress("0xF25E764a2222532008D89FC018E70c18DD2401C2"): "Validator owner",
+		common.HexToAddress("0x4e4620FE9dF2751F55FA01D24413343290c22698"): "Validator owner",
+		common.HexToAddress("0xf299AfC34ec0B9dCAF868914288d735149d6306f"): "Validator owner",
+		common.HexToAddress("0x9a905C99D7753F01918E389C785b5862CF7A3945"): "Validator owner",
+		common.HexToAddress("0x19d0bc6d0Ca394E3547fF06A0F2805dB623dEcA8"): "Validator owner",
+		common.HexToAddress("0x7F420438941EB35bCe2E7C6824B8f9c04Ad4f188"): "Validator owner",
+		common.HexToAddress("0xdC3A7153A2afB491B94784d86d6A915Ce5dde102"): "Validator owner",
+		common.HexToAddress("0x8a999c490793f9d340Be71Ea4Ae81E9C627bD0cd"): "Validator owner",
+		common.HexToAddress("0x6d25F93FAb44a7651dd52B3560ac74d98e1f912C"): "Validator owner",
+		common.HexToAddress("0x4b045692540E6B7AfDE44cdad60136d170efc623"): "Bridge relayer",
+		common.HexToAddress("0xb0AdF650ABDc7d2d5ac7366888ab492e9Df8589A"): "Bridge relayer",
+		common.HexToAddress("0x52f30AefB50B5d271d93A10730088733Bdbe31E0"): "Bridge relayer",
+		common.HexToAddress("0x1Cb83A71d81DaCe297975e377777c94a32d9D5dD"): "Bridge relayer",
+		common.HexToAddress("0xAE68F408160C40d508834734aC5bEd773a36e9D2"): "Bridge relayer",
+		common.HexToAddress("0x3665dfcdaf8310684c24592b017D986A993320e6"): "Bridge relayer",
+		common.HexToAddress("0x252B5CA6c838ae47508c1eA72Dd73b58c607Af0f"): "Bridge relayer",
+	},
+	Supply: &supplyConstraints{
+		Total: "8,888,888,888",
+		Labels: map[string]string{
+			"Treasury":        "8,738,880,288",
+			"Staking":         "150,000,000", // 15 validators with 10,000,000 CHZ initial stake
+			"Deployer":        "100",
+			"Validator owner": "1,500", // 15 accounts with 100 CHZ
+			"Bridge relayer":  "7,000", // 7 accounts with 1,000 CHZ
+		},
+	},
+	Forks: ChilizForks{
+		RuntimeUpgradeBlock:    (*math.HexOrDecimal256)(big.NewInt(0)),
+		DeployOriginBlock:      (*math.HexOrDecimal256)(big.NewInt(0)),
+		DeploymentHookFixBlock: (*math.HexOrDecimal256)(big.NewInt(0)),
+		DeployerFactoryBlock:   nil, // TODO: "specify fork block here"
+	},
+}
+
+func TestNetworkConfigOverlays(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		expected genesisConfig
+	}{
+		{"devnet", expectedDevNetConfig},
+		{"testnet", expectedTestNetConfig},
+		{"spicy", expectedSpicyConfig},
+		{"mainnet", expectedMainNetConfig},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			config, err := readGenesisConfig(networkConfigFiles.ReadFile, "configs/networks/"+test.name+".json")
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected, _ := json.MarshalIndent(test.expected, "", "  ")
+			resolved, _ := json.MarshalIndent(config, "", "  ")
+			if string(expected) != string(resolved) {
+				t.Fatalf("resolved config differs from the expected one\nexpected: %s\nresolved: %s", expected, resolved)
+			}
+		})
+	}
+}
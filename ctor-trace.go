@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/eth/tracers/logger"
+	_ "github.com/ethereum/go-ethereum/eth/tracers/native"
+)
+
+const defaultTraceDir = "traces"
+
+// ctorTraceConfig enables tracing of system contract's constructor and init function, every contract gets
+// two trace files in the chain's subdirectory: <Dir>/<ChainId>/<Contract>.ctor.json and <Contract>.init.json,
+// so concurrent builds of different networks don't overwrite each other's traces
+type ctorTraceConfig struct {
+	// one of callTracer, prestateTracer, diffTracer (prestateTracer in diff mode) or structLogger (opcode level)
+	Tracer string `json:"tracer"`
+	// output directory for trace files ("traces" by default), traces are written into its chain ID subdirectory
+	Dir string `json:"dir,omitempty"`
+}
+
+func newCtorTracer(name string) (tracers.Tracer, error) {
+	switch name {
+	case "structLogger":
+		return logger.NewStructLogger(&logger.Config{EnableReturnData: true}), nil
+	case "diffTracer":
+		return tracers.DefaultDirectory.New("prestateTracer", &tracers.Context{}, json.RawMessage(`{"diffMode":true}`))
+	case "callTracer", "prestateTracer":
+		return tracers.DefaultDirectory.New(name, &tracers.Context{}, nil)
+	}
+	return nil, fmt.Errorf("unknown tracer (%s), supported tracers are callTracer, prestateTracer, diffTracer and structLogger", name)
+}
+
+// prepare validates tracer name and creates output directory of the chain, it must be called before the simulation,
+// returned config is a copy since the same config can be shared by builds of several networks
+func (c *ctorTraceConfig) prepare(chainId int64) (*ctorTraceConfig, error) {
+	if _, err := newCtorTracer(c.Tracer); err != nil {
+		return nil, err
+	}
+	prepared := *c
+	if prepared.Dir == "" {
+		prepared.Dir = defaultTraceDir
+	}
+	prepared.Dir = filepath.Join(prepared.Dir, strconv.FormatInt(chainId, 10))
+	return &prepared, os.MkdirAll(prepared.Dir, 0755)
+}
+
+// start attaches a new tracer to the EVM, nothing happens if tracing is disabled
+func (c *ctorTraceConfig) start(evm *vm.EVM, gasLimit uint64) (tracers.Tracer, error) {
+	if c == nil {
+		return nil, nil
+	}
+	tracer, err := newCtorTracer(c.Tracer)
+	if err != nil {
+		return nil, err
+	}
+	evm.Config.Tracer = tracer
+	// there is no state transition around the call, so tx boundaries must be reported to the tracer manually
+	tracer.CaptureTxStart(gasLimit)
+	return tracer, nil
+}
+
+// write saves trace of the stage, it's called even if the stage failed since failed executions are the ones to debug
+func (c *ctorTraceConfig) write(tracer tracers.Tracer, contract string, stage string, leftOverGas uint64) error {
+	if c == nil || tracer == nil {
+		return nil
+	}
+	tracer.CaptureTxEnd(leftOverGas)
+	result, err := tracer.GetResult()
+	if err != nil {
+		return fmt.Errorf("failed to get %s trace of %s: %w", stage, contract, err)
+	}
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, result, "", "  "); err != nil {
+		return err
+	}
+	indented.WriteByte('\n')
+	return os.WriteFile(filepath.Join(c.Dir, fmt.Sprintf("%s.%s.json", contract, stage)), indented.Bytes(), 0644)
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/prysmaticlabs/prysm/v5/crypto/bls"
+)
+
+const extraVanity = 32
+const extraSeal = 65
+
+// blsPublicKeyLength is a length of compressed BLS public key (validator's vote address)
+const blsPublicKeyLength = 48
+
+// lubanAtGenesis returns true if the config activates Luban (fast finality) at genesis block
+func lubanAtGenesis(config genesisConfig) bool {
+	return config.Forks.LubanBlock != nil && decimalToBigInt(config.Forks.LubanBlock).Sign() == 0
+}
+
+// validateVoteAddresses checks that every vote address is a valid BLS public key of a genesis validator,
+// if Luban is active at genesis then every validator must have a vote address
+func validateVoteAddresses(config genesisConfig) error {
+	isValidator := make(map[common.Address]bool)
+	for _, v := range config.Validators {
+		isValidator[v] = true
+	}
+	owners := make(map[string]common.Address)
+	for _, validator := range sortedAddresses(config.VoteAddresses) {
+		voteAddress := config.VoteAddresses[validator]
+		if !isValidator[validator] {
+			return fmt.Errorf("vote address is specified for unknown validator (%s)", validator.Hex())
+		}
+		if len(voteAddress) != blsPublicKeyLength {
+			return fmt.Errorf("bad vote address length of validator (%s), expected %d bytes but got %d", validator.Hex(), blsPublicKeyLength, len(voteAddress))
+		}
+		if _, err := bls.PublicKeyFromBytes(voteAddress); err != nil {
+			return fmt.Errorf("bad vote address of validator (%s): %w", validator.Hex(), err)
+		}
+		if owner, ok := owners[string(voteAddress)]; ok {
+			return fmt.Errorf("vote address of validator (%s) is already used by validator (%s)", validator.Hex(), owner.Hex())
+		}
+		owners[string(voteAddress)] = validator
+	}
+	if lubanAtGenesis(config) {
+		if len(config.Validators) > 255 {
+			return fmt.Errorf("too many genesis validators (%d) for Luban extra data", len(config.Validators))
+		}
+		for _, v := range config.Validators {
+			if _, ok := config.VoteAddresses[v]; !ok {
+				return fmt.Errorf("vote address is not found for validator (%s), it's required since Luban is active at genesis", v.Hex())
+			}
+		}
+	}
+	return nil
+}
+
+// createLubanExtraData creates extraData of the Luban layout: vanity, validators count, validators with
+// their BLS vote addresses and seal, vote addresses must be validated before
+func createLubanExtraData(validators []common.Address, voteAddresses map[common.Address]hexutil.Bytes) []byte {
+	const validatorBytesLength = common.AddressLength + blsPublicKeyLength
+	extra := make([]byte, extraVanity+1+validatorBytesLength*len(validators)+extraSeal)
+	extra[extraVanity] = byte(len(validators))
+	for i, v := range validators {
+		offset := extraVanity + 1 + validatorBytesLength*i
+		copy(extra[offset:], v.Bytes())
+		copy(extra[offset+common.AddressLength:], voteAddresses[v])
+	}
+	return extra
+}
+
+// parseExtraDataValidators decodes validators from the legacy (vanity, validators, seal) or Luban extraData layout,
+// vote addresses are returned for the Luban layout only
+func parseExtraDataValidators(extra []byte, luban bool) ([]common.Address, []hexutil.Bytes, error) {
+	if len(extra) < extraVanity+extraSeal {
+		return nil, nil, fmt.Errorf("bad extra data length (%d)", len(extra))
+	}
+	var validators []common.Address
+	var voteAddresses []hexutil.Bytes
+	if !luban {
+		if (len(extra)-extraVanity-extraSeal)%common.AddressLength != 0 {
+			return nil, nil, fmt.Errorf("bad extra data length (%d)", len(extra))
+		}
+		for i := extraVanity; i < len(extra)-extraSeal; i += common.AddressLength {
+			validators = append(validators, common.BytesToAddress(extra[i:i+common.AddressLength]))
+		}
+		return validators, nil, nil
+	}
+	const validatorBytesLength = common.AddressLength + blsPublicKeyLength
+	count := int(extra[extraVanity])
+	if len(extra) != extraVanity+1+validatorBytesLength*count+extraSeal {
+		return nil, nil, fmt.Errorf("bad extra data length (%d) for %d validators", len(extra), count)
+	}
+	for i := 0; i < count; i++ {
+		offset := extraVanity + 1 + validatorBytesLength*i
+		validators = append(validators, common.BytesToAddress(extra[offset:offset+common.AddressLength]))
+		voteAddresses = append(voteAddresses, common.CopyBytes(extra[offset+common.AddressLength:offset+validatorBytesLength]))
+	}
+	return validators, voteAddresses, nil
+}
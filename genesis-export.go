@@ -0,0 +1,128 @@
+package main
+
+import (
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// genesisOutputs lists files the genesis is exported to, empty file names are skipped
+type genesisOutputs struct {
+	Genesis string
+	// Compact disables indentation of JSON outputs
+	Compact bool
+	// StripAlloc removes alloc from the genesis document (useful with separate alloc file)
+	StripAlloc bool
+	Alloc      string
+	// Header is a hex encoded RLP of the genesis block header
+	Header    string
+	Chainspec string
+}
+
+type chainspecSystemContract struct {
+	Name    string         `json:"name"`
+	Address common.Address `json:"address"`
+}
+
+// chainspec is a minimal description of the chain for explorers and indexers
+type chainspec struct {
+	ChainId     *big.Int    `json:"chainId"`
+	GenesisHash common.Hash `json:"genesisHash"`
+	GenesisTime uint64      `json:"genesisTime"`
+	Consensus   struct {
+		Engine string `json:"engine"`
+		Period uint64 `json:"period"`
+		Epoch  uint64 `json:"epoch"`
+	} `json:"consensus"`
+	// BlockForks and TimeForks contain activated or scheduled forks only
+	BlockForks      map[string]uint64         `json:"blockForks"`
+	TimeForks       map[string]uint64         `json:"timeForks"`
+	SystemContracts []chainspecSystemContract `json:"systemContracts"`
+}
+
+// chainForks reads all "*Block" and "*Time" forks of the chain config, fork names are taken from json tags
+func chainForks(config *params.ChainConfig) (map[string]uint64, map[string]uint64) {
+	blockForks, timeForks := make(map[string]uint64), make(map[string]uint64)
+	value := reflect.ValueOf(config).Elem()
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		switch v := value.Field(i).Interface().(type) {
+		case *big.Int:
+			if v != nil && strings.HasSuffix(field.Name, "Block") {
+				blockForks[name] = v.Uint64()
+			}
+		case *uint64:
+			if v != nil && strings.HasSuffix(field.Name, "Time") {
+				timeForks[name] = *v
+			}
+		}
+	}
+	return blockForks, timeForks
+}
+
+func newChainspec(genesis *core.Genesis) *chainspec {
+	result := &chainspec{
+		ChainId:     genesis.Config.ChainID,
+		GenesisHash: genesis.ToBlock().Hash(),
+		GenesisTime: genesis.Timestamp,
+	}
+	result.Consensus.Engine = "parlia"
+	if genesis.Config.Parlia != nil {
+		result.Consensus.Period = genesis.Config.Parlia.Period
+		result.Consensus.Epoch = genesis.Config.Parlia.Epoch
+	}
+	result.BlockForks, result.TimeForks = chainForks(genesis.Config)
+	for _, c := range systemContracts {
+		result.SystemContracts = append(result.SystemContracts, chainspecSystemContract{Name: c.Name, Address: c.Address})
+	}
+	return result
+}
+
+func (o genesisOutputs) marshal(v interface{}) []byte {
+	var result []byte
+	if o.Compact {
+		result, _ = json.Marshal(v)
+	} else {
+		result, _ = json.MarshalIndent(v, "", "  ")
+	}
+	return append(result, '\n')
+}
+
+// writeGenesisOutputs exports genesis in all requested formats
+func writeGenesisOutputs(genesis *core.Genesis, outputs genesisOutputs) error {
+	if outputs.Alloc != "" {
+		if err := writeOutputFile(outputs.Alloc, outputs.marshal(genesis.Alloc)); err != nil {
+			return err
+		}
+	}
+	if outputs.Header != "" {
+		header, err := rlp.EncodeToBytes(genesis.ToBlock().Header())
+		if err != nil {
+			return err
+		}
+		if err := writeOutputFile(outputs.Header, []byte(hexutil.Encode(header)+"\n")); err != nil {
+			return err
+		}
+	}
+	if outputs.Chainspec != "" {
+		if err := writeOutputFile(outputs.Chainspec, outputs.marshal(newChainspec(genesis))); err != nil {
+			return err
+		}
+	}
+	if outputs.Genesis != "" {
+		document := *genesis
+		if outputs.StripAlloc {
+			document.Alloc = nil
+		}
+		return writeOutputFile(outputs.Genesis, outputs.marshal(&document))
+	}
+	return nil
+}
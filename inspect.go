@@ -0,0 +1,274 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+)
+
+type genesisValidatorSummary struct {
+	Address     common.Address `json:"address"`
+	VoteAddress hexutil.Bytes  `json:"voteAddress,omitempty"`
+	Label       string         `json:"label,omitempty"`
+}
+
+type systemContractSummary struct {
+	Name     string         `json:"name"`
+	Address  common.Address `json:"address"`
+	CodeSize int            `json:"codeSize"`
+	// CodeHash is a hash of the runtime code w/o metadata (like in verify and build reports)
+	CodeHash *common.Hash `json:"codeHash,omitempty"`
+	Release  string       `json:"release,omitempty"`
+	Balance  *big.Int     `json:"balance"`
+}
+
+type balanceSummary struct {
+	Address common.Address `json:"address"`
+	Label   string         `json:"label,omitempty"`
+	Balance *big.Int       `json:"balance"`
+}
+
+// genesisSummary is a human-readable description of the genesis file
+type genesisSummary struct {
+	ChainId *big.Int `json:"chainId"`
+	// GenesisHash is empty if genesis has no alloc (alloc is stored separately)
+	GenesisHash *common.Hash      `json:"genesisHash,omitempty"`
+	Timestamp   uint64            `json:"timestamp"`
+	GasLimit    uint64            `json:"gasLimit"`
+	BlockForks  map[string]uint64 `json:"blockForks"`
+	TimeForks   map[string]uint64 `json:"timeForks"`
+	Parlia      *struct {
+		Period uint64 `json:"period"`
+		Epoch  uint64 `json:"epoch"`
+	} `json:"parlia,omitempty"`
+	Validators      []genesisValidatorSummary `json:"validators"`
+	SystemContracts []systemContractSummary   `json:"systemContracts"`
+	TopBalances     []balanceSummary          `json:"topBalances"`
+	Accounts        int                       `json:"accounts"`
+	TotalSupply     *big.Int                  `json:"totalSupply"`
+	Warnings        []string                  `json:"warnings,omitempty"`
+}
+
+// accountLabel describes roles of the account, config roles are known only if config is given
+func accountLabel(config *genesisConfig, address common.Address) string {
+	if c, ok := systemContractByAddress(address); ok {
+		return c.Name
+	}
+	if config == nil {
+		return ""
+	}
+	var roles []string
+	if label, ok := config.FaucetLabels[address]; ok {
+		roles = append(roles, label)
+	}
+	for _, v := range config.Validators {
+		if v == address {
+			roles = append(roles, "validator")
+		}
+	}
+	for _, d := range config.Deployers {
+		if d == address {
+			roles = append(roles, "deployer")
+		}
+	}
+	if _, ok := config.SystemTreasury[address]; ok {
+		roles = append(roles, "system treasury")
+	}
+	if _, ok := config.Faucet[address]; ok && len(roles) == 0 {
+		roles = append(roles, "faucet")
+	}
+	return strings.Join(roles, ", ")
+}
+
+// inspectGenesis summarizes the genesis, top balances are limited by topBalances (all accounts if zero)
+func inspectGenesis(genesis *core.Genesis, config *genesisConfig, topBalances int) (*genesisSummary, error) {
+	if genesis.Config == nil {
+		return nil, fmt.Errorf("genesis has no chain config")
+	}
+	summary := &genesisSummary{
+		ChainId:     genesis.Config.ChainID,
+		Timestamp:   genesis.Timestamp,
+		GasLimit:    genesis.GasLimit,
+		Accounts:    len(genesis.Alloc),
+		TotalSupply: genesisTotalSupply(genesis.Alloc),
+	}
+	if len(genesis.Alloc) > 0 {
+		hash := genesis.ToBlock().Hash()
+		summary.GenesisHash = &hash
+	}
+	summary.BlockForks, summary.TimeForks = chainForks(genesis.Config)
+	if genesis.Config.Parlia != nil {
+		summary.Parlia = &struct {
+			Period uint64 `json:"period"`
+			Epoch  uint64 `json:"epoch"`
+		}{genesis.Config.Parlia.Period, genesis.Config.Parlia.Epoch}
+	}
+	// only parlia keeps validators in extra data, other engines use it differently
+	var validators []common.Address
+	var voteAddresses []hexutil.Bytes
+	if genesis.Config.Parlia != nil {
+		var err error
+		if validators, voteAddresses, err = parseExtraDataValidators(genesis.ExtraData, genesis.Config.IsLuban(common.Big0)); err != nil {
+			summary.Warnings = append(summary.Warnings, fmt.Sprintf("failed to decode validators from extra data: %v", err))
+		}
+	}
+	for i, v := range validators {
+		validator := genesisValidatorSummary{Address: v, Label: accountLabel(config, v)}
+		if voteAddresses != nil {
+			validator.VoteAddress = voteAddresses[i]
+		}
+		summary.Validators = append(summary.Validators, validator)
+	}
+	for _, c := range systemContracts {
+		entry := systemContractSummary{Name: c.Name, Address: c.Address, Balance: big.NewInt(0)}
+		if account, ok := genesis.Alloc[c.Address]; ok {
+			entry.CodeSize = len(account.Code)
+			if len(account.Code) > 0 {
+				hash := runtimeCodeHash(account.Code)
+				entry.CodeHash, entry.Release = &hash, knownCodeReleases.release(c.Name, account.Code)
+			}
+			if account.Balance != nil {
+				entry.Balance = account.Balance
+			}
+		}
+		summary.SystemContracts = append(summary.SystemContracts, entry)
+	}
+	for address, account := range genesis.Alloc {
+		if account.Balance == nil || account.Balance.Sign() == 0 {
+			continue
+		}
+		summary.TopBalances = append(summary.TopBalances, balanceSummary{Address: address, Label: accountLabel(config, address), Balance: account.Balance})
+	}
+	sort.Slice(summary.TopBalances, func(i, j int) bool {
+		if c := summary.TopBalances[i].Balance.Cmp(summary.TopBalances[j].Balance); c != 0 {
+			return c > 0
+		}
+		return summary.TopBalances[i].Address.Cmp(summary.TopBalances[j].Address) < 0
+	})
+	if topBalances > 0 && len(summary.TopBalances) > topBalances {
+		summary.TopBalances = summary.TopBalances[:topBalances]
+	}
+	return summary, nil
+}
+
+func sortedForks(forks map[string]uint64) []string {
+	names := make([]string, 0, len(forks))
+	for name := range forks {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if forks[names[i]] != forks[names[j]] {
+			return forks[names[i]] < forks[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
+
+func printGenesisSummary(summary *genesisSummary) {
+	fmt.Printf("chain id: %s\n", summary.ChainId)
+	if summary.GenesisHash != nil {
+		fmt.Printf("genesis hash: %s\n", summary.GenesisHash.Hex())
+	}
+	fmt.Printf("timestamp: %d (%s)\n", summary.Timestamp, time.Unix(int64(summary.Timestamp), 0).UTC().Format(time.RFC3339))
+	fmt.Printf("gas limit: %d\n", summary.GasLimit)
+	if summary.Parlia != nil {
+		fmt.Printf("parlia: period %ds, epoch %d blocks\n", summary.Parlia.Period, summary.Parlia.Epoch)
+	}
+	fmt.Printf("\n%-32s %s\n", "fork", "activation")
+	for _, name := range sortedForks(summary.BlockForks) {
+		fmt.Printf("%-32s block %d\n", name, summary.BlockForks[name])
+	}
+	for _, name := range sortedForks(summary.TimeForks) {
+		at := summary.TimeForks[name]
+		fmt.Printf("%-32s time %d (%s)\n", name, at, time.Unix(int64(at), 0).UTC().Format(time.RFC3339))
+	}
+	fmt.Printf("\n%-44s %-98s %s\n", "validator", "vote address", "label")
+	for _, v := range summary.Validators {
+		voteAddress := "-"
+		if v.VoteAddress != nil {
+			voteAddress = v.VoteAddress.String()
+		}
+		fmt.Printf("%-44s %-98s %s\n", v.Address.Hex(), voteAddress, v.Label)
+	}
+	fmt.Printf("\n%-20s %-44s %10s %-68s %-20s %s\n", "system contract", "address", "code size", "code hash", "release", "balance")
+	for _, c := range summary.SystemContracts {
+		codeHash := "not deployed"
+		if c.CodeHash != nil {
+			codeHash = c.CodeHash.Hex()
+		}
+		fmt.Printf("%-20s %-44s %10d %-68s %-20s %s\n", c.Name, c.Address.Hex(), c.CodeSize, codeHash, c.Release, formatAmount(c.Balance))
+	}
+	fmt.Printf("\n%-44s %-32s %36s\n", "account", "label", "balance")
+	for _, b := range summary.TopBalances {
+		fmt.Printf("%-44s %-32s %36s\n", b.Address.Hex(), b.Label, formatAmount(b.Balance))
+	}
+	fmt.Printf("%-44s %-32s %36s\n", "total supply", fmt.Sprintf("%d accounts", summary.Accounts), formatAmount(summary.TotalSupply))
+	for _, warning := range summary.Warnings {
+		fmt.Printf("WARN: %s\n", warning)
+	}
+}
+
+func runInspectCommand(args []string) error {
+	flags := flag.NewFlagSet("inspect", flag.ContinueOnError)
+	genesisFile := flags.String("genesis", "", "genesis file (built-in network's genesis by default)")
+	networkName := flags.String("network", "", "built-in network used for account labels: localnet, devnet, testnet, spicy or mainnet")
+	configFile := flags.String("config", "", "network config file used for account labels (instead of built-in network)")
+	top := flags.Int("top", 20, "number of top balances to show (0 shows all accounts)")
+	format := flags.String("format", "table", "output format: table or json")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	var config *genesisConfig
+	switch {
+	case *networkName != "":
+		network, ok := builtinNetworkByName(*networkName)
+		if !ok {
+			return fmt.Errorf("unknown network (%s)", *networkName)
+		}
+		config = &network.Config
+		if *genesisFile == "" {
+			*genesisFile = network.GenesisFile
+		}
+	case *configFile != "":
+		c, err := readGenesisConfigFile(*configFile)
+		if err != nil {
+			return err
+		}
+		config = c
+	}
+	if *genesisFile == "" {
+		return fmt.Errorf("genesis file or network is required")
+	}
+	fileContents, err := os.ReadFile(*genesisFile)
+	if err != nil {
+		return err
+	}
+	genesis := &core.Genesis{}
+	if err := json.Unmarshal(fileContents, genesis); err != nil {
+		return fmt.Errorf("failed to parse genesis file (%s): %w", *genesisFile, err)
+	}
+	summary, err := inspectGenesis(genesis, config, *top)
+	if err != nil {
+		return err
+	}
+	switch *format {
+	case "json":
+		result, _ := json.MarshalIndent(summary, "", "  ")
+		os.Stdout.Write(append(result, '\n'))
+	case "table":
+		printGenesisSummary(summary)
+	default:
+		return fmt.Errorf("unknown output format (%s)", *format)
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// protectedNetworks are networks whose keys must never be committed into the repository
+var protectedNetworks = []string{"mainnet", "spicy"}
+
+type keystoreRole struct {
+	Role     string         `json:"role"`
+	Address  common.Address `json:"address"`
+	Keystore string         `json:"keystore,omitempty"`
+}
+
+type keystoreAuditReport struct {
+	Network string         `json:"network"`
+	Roles   []keystoreRole `json:"roles"`
+	Unused  []string       `json:"unused"`
+	// Broken contains files that can't be decrypted with the password
+	Broken map[string]string `json:"broken,omitempty"`
+	// Leaked contains roles of protected networks controlled by keys from the directory
+	Leaked []string `json:"leaked,omitempty"`
+}
+
+// networkRoles lists accounts of the config that are expected to have keys
+func networkRoles(config genesisConfig) []keystoreRole {
+	var roles []keystoreRole
+	for _, v := range config.Validators {
+		roles = append(roles, keystoreRole{Role: "validator", Address: v})
+	}
+	for _, d := range config.Deployers {
+		roles = append(roles, keystoreRole{Role: "deployer", Address: d})
+	}
+	for _, t := range sortedAddresses(config.SystemTreasury) {
+		roles = append(roles, keystoreRole{Role: "system treasury", Address: t})
+	}
+	for _, f := range sortedAddresses(config.Faucet) {
+		roles = append(roles, keystoreRole{Role: "faucet", Address: f})
+	}
+	return roles
+}
+
+// decryptKeystoreDir decrypts all keystore files of the directory, files that aren't keystores are skipped
+func decryptKeystoreDir(dir string, password string) (map[common.Address]string, map[string]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	keys := make(map[common.Address]string)
+	broken := make(map[string]string)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		fileName := filepath.Join(dir, entry.Name())
+		keyJson, err := os.ReadFile(fileName)
+		if err != nil {
+			return nil, nil, err
+		}
+		var probe struct {
+			Crypto json.RawMessage `json:"crypto"`
+		}
+		if json.Unmarshal(keyJson, &probe) != nil || probe.Crypto == nil {
+			continue
+		}
+		key, err := keystore.DecryptKey(keyJson, password)
+		if err != nil {
+			broken[fileName] = err.Error()
+			continue
+		}
+		keys[key.Address] = fileName
+	}
+	return keys, broken, nil
+}
+
+func auditKeystores(network string, config genesisConfig, keys map[common.Address]string) *keystoreAuditReport {
+	report := &keystoreAuditReport{Network: network}
+	used := make(map[string]bool)
+	for _, role := range networkRoles(config) {
+		role.Keystore = keys[role.Address]
+		if role.Keystore != "" {
+			used[role.Keystore] = true
+		}
+		report.Roles = append(report.Roles, role)
+	}
+	for _, name := range protectedNetworks {
+		protected, _ := builtinNetworkByName(name)
+		for _, role := range networkRoles(protected.Config) {
+			if fileName, ok := keys[role.Address]; ok {
+				report.Leaked = append(report.Leaked, fmt.Sprintf("%s of %s (%s) is controlled by the committed key %s", role.Role, name, role.Address.Hex(), fileName))
+			}
+		}
+	}
+	for _, fileName := range keys {
+		if !used[fileName] {
+			report.Unused = append(report.Unused, fileName)
+		}
+	}
+	sort.Strings(report.Unused)
+	return report
+}
+
+func printKeystoreAuditReport(report *keystoreAuditReport) {
+	fmt.Printf("keystore audit of %s\n", report.Network)
+	for _, role := range report.Roles {
+		if role.Keystore != "" {
+			fmt.Printf(" + %s %s: %s\n", role.Role, role.Address.Hex(), role.Keystore)
+		} else {
+			fmt.Printf(" - %s %s: no keystore\n", role.Role, role.Address.Hex())
+		}
+	}
+	for _, fileName := range report.Unused {
+		fmt.Printf(" ~ unused keystore %s\n", fileName)
+	}
+	for fileName, reason := range report.Broken {
+		fmt.Printf(" ~ failed to decrypt %s: %s\n", fileName, reason)
+	}
+}
+
+func runKeystoreAuditCommand(args []string) error {
+	flags := flag.NewFlagSet("keystore-audit", flag.ContinueOnError)
+	dir := flags.String("dir", "keystore", "directory with V3 keystore files")
+	passwordFile := flags.String("password", "password.txt", "file with keystore password")
+	networkName := flags.String("network", "", "built-in network: localnet, devnet, testnet, spicy or mainnet")
+	configFile := flags.String("config", "", "network config file (instead of built-in network)")
+	format := flags.String("format", "text", "report format: text or json")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	var config genesisConfig
+	name := *networkName
+	switch {
+	case *networkName != "":
+		network, ok := builtinNetworkByName(*networkName)
+		if !ok {
+			return fmt.Errorf("unknown network (%s)", *networkName)
+		}
+		config = network.Config
+	case *configFile != "":
+		c, err := readGenesisConfigFile(*configFile)
+		if err != nil {
+			return err
+		}
+		config, name = *c, *configFile
+	default:
+		return fmt.Errorf("network or config file is required")
+	}
+	password, err := readPasswordFile(*passwordFile)
+	if err != nil {
+		return err
+	}
+	keys, broken, err := decryptKeystoreDir(*dir, password)
+	if err != nil {
+		return err
+	}
+	report := auditKeystores(name, config, keys)
+	report.Broken = broken
+	switch *format {
+	case "json":
+		result, _ := json.MarshalIndent(report, "", "  ")
+		os.Stdout.Write(append(result, '\n'))
+	case "text":
+		printKeystoreAuditReport(report)
+	default:
+		return fmt.Errorf("unknown report format (%s)", *format)
+	}
+	if len(report.Leaked) > 0 {
+		return fmt.Errorf("PUBLICLY COMMITTED KEYS CONTROL PROTECTED NETWORK ACCOUNTS, rotate them immediately:\n - %s", strings.Join(report.Leaked, "\n - "))
+	}
+	return nil
+}
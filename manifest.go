@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// manifestVersion must be increased on every incompatible change of the manifest format
+const manifestVersion = 2
+
+type manifestCtorParam struct {
+	Type  string      `json:"type"`
+	Value interface{} `json:"value"`
+}
+
+// systemContractManifestEntry isn't deployed if the contract is missing in the genesis alloc (e.g. it's added after
+// the network launch), such entry has no code hash
+type systemContractManifestEntry struct {
+	Name       string              `json:"name"`
+	Address    common.Address      `json:"address"`
+	ABI        json.RawMessage     `json:"abi"`
+	Deployed   bool                `json:"deployed"`
+	CodeHash   *common.Hash        `json:"codeHash,omitempty"`
+	CtorParams []manifestCtorParam `json:"ctorParams"`
+}
+
+// systemContractsManifest is a single source of system contract addresses, ABIs and code hashes of the network
+type systemContractsManifest struct {
+	Version         int                           `json:"version"`
+	Network         string                        `json:"network,omitempty"`
+	ChainId         int64                         `json:"chainId"`
+	GenesisHash     common.Hash                   `json:"genesisHash"`
+	SystemContracts []systemContractManifestEntry `json:"systemContracts"`
+}
+
+// createSystemContractsManifest describes system contracts of the genesis, ctor params are restored from the config
+func createSystemContractsManifest(network string, config genesisConfig, genesis *core.Genesis) (*systemContractsManifest, error) {
+	validators, _, err := canonicalValidatorSet(config)
+	if err != nil {
+		return nil, err
+	}
+	config.Validators = validators
+	constructors, err := genesisConstructors(config)
+	if err != nil {
+		return nil, err
+	}
+	manifest := &systemContractsManifest{
+		Version:     manifestVersion,
+		Network:     network,
+		ChainId:     config.ChainId,
+		GenesisHash: genesis.ToBlock().Hash(),
+	}
+	for _, ctor := range constructors {
+		contract, _ := systemContractByAddress(ctor.Address)
+		artifact, err := parseArtifact(ctor.RawArtifact)
+		if err != nil {
+			return nil, err
+		}
+		entry := systemContractManifestEntry{
+			Name:       contract.Name,
+			Address:    ctor.Address,
+			ABI:        artifact.ABI,
+			CtorParams: []manifestCtorParam{},
+		}
+		if account, ok := genesis.Alloc[ctor.Address]; ok && len(account.Code) > 0 {
+			hash := crypto.Keccak256Hash(account.Code)
+			entry.Deployed, entry.CodeHash = true, &hash
+		}
+		for i, typeName := range ctor.TypeNames {
+			entry.CtorParams = append(entry.CtorParams, manifestCtorParam{Type: typeName, Value: ctor.Params[i]})
+		}
+		manifest.SystemContracts = append(manifest.SystemContracts, entry)
+	}
+	return manifest, nil
+}
+
+func runManifestCommand(args []string) error {
+	flags := flag.NewFlagSet("manifest", flag.ContinueOnError)
+	networkName := flags.String("network", "", "built-in network: localnet, devnet, testnet, spicy or mainnet")
+	configFile := flags.String("config", "", "network config file (instead of built-in network)")
+	genesisFile := flags.String("genesis", "", "genesis file of the network (built-in network's genesis by default, built from config if not specified)")
+	outputFile := flags.String("output", "stdout", "output file")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	var config genesisConfig
+	switch {
+	case *networkName != "":
+		network, ok := builtinNetworkByName(*networkName)
+		if !ok {
+			return fmt.Errorf("unknown network (%s)", *networkName)
+		}
+		config = network.Config
+		if *genesisFile == "" {
+			*genesisFile = network.GenesisFile
+		}
+	case *configFile != "":
+		c, err := readGenesisConfigFile(*configFile)
+		if err != nil {
+			return err
+		}
+		config = *c
+	default:
+		return fmt.Errorf("network or config file is required")
+	}
+	var genesis *core.Genesis
+	if *genesisFile != "" {
+		source, err := readStateSource(*genesisFile)
+		if err != nil {
+			return err
+		}
+		if source.Genesis == nil {
+			return fmt.Errorf("file (%s) is not a genesis file", *genesisFile)
+		}
+		genesis = source.Genesis
+	} else {
+		var err error
+		genesis, _, err = buildGenesis(config, "", false, io.Discard)
+		if err != nil {
+			return err
+		}
+	}
+	manifest, err := createSystemContractsManifest(*networkName, config, genesis)
+	if err != nil {
+		return err
+	}
+	result, _ := json.MarshalIndent(manifest, "", "  ")
+	return writeOutputFile(*outputFile, append(result, '\n'))
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core"
+)
+
+// buildSlots is the single limiter of build concurrency, it's shared by network builds and system contract simulations
+// within them, so nested tasks don't multiply the number of workers
+var buildSlots = newBuildSlots(runtime.NumCPU())
+
+// newBuildSlots creates limiter for the given number of workers, the goroutine that runs tasks is a worker as well
+func newBuildSlots(workers int) chan struct{} {
+	if workers < 1 {
+		workers = 1
+	}
+	return make(chan struct{}, workers-1)
+}
+
+// runParallel executes tasks using free slots of the build limiter, task runs on the calling goroutine if there is no free
+// slot, so nested calls never wait for slots held by their parents, errors are joined in task order,
+// so the result doesn't depend on scheduling
+func runParallel(tasks int, task func(i int) error) error {
+	slots := buildSlots
+	errs := make([]error, tasks)
+	var wg sync.WaitGroup
+	for i := 0; i < tasks; i++ {
+		select {
+		case slots <- struct{}{}:
+			wg.Add(1)
+			go func(i int) {
+				defer func() {
+					<-slots
+					wg.Done()
+				}()
+				errs[i] = runTask(i, task)
+			}(i)
+		default:
+			errs[i] = runTask(i, task)
+		}
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// runTask turns panic of the task into error, otherwise single bad task would crash the whole pool
+func runTask(i int, task func(i int) error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("task %d panicked: %v", i, r)
+		}
+	}()
+	return task(i)
+}
+
+// networkBuild is a result of the built-in network build, log is buffered to be printed in network order
+type networkBuild struct {
+	Genesis *core.Genesis
+	Report  *genesisBuildReport
+	Log     bytes.Buffer
+}
+
+// buildNetworks builds genesis of all networks concurrently, logs of failed builds are kept as well
+func buildNetworks(networks []builtinNetwork) ([]*networkBuild, error) {
+	builds := make([]*networkBuild, len(networks))
+	for i := range builds {
+		builds[i] = &networkBuild{}
+	}
+	err := runParallel(len(networks), func(i int) error {
+		network, build := networks[i], builds[i]
+		fmt.Fprintf(&build.Log, "building %s\n", network.Title)
+		var err error
+		build.Genesis, build.Report, err = buildGenesis(network.Config, network.GenesisFile, network.UpdateOnlyConfig, &build.Log)
+		if err != nil {
+			return fmt.Errorf("failed to build %s: %w", network.Name, err)
+		}
+		return nil
+	})
+	return builds, err
+}
+
+// buildOutputs encodes genesis of each network, timestamp is reset since new networks are created with current time
+func buildOutputs(builds []*networkBuild) [][]byte {
+	outputs := make([][]byte, len(builds))
+	for i, build := range builds {
+		genesis := *build.Genesis
+		genesis.Timestamp = 0
+		outputs[i], _ = json.MarshalIndent(&genesis, "", "  ")
+	}
+	return outputs
+}
+
+func timeNetworkBuilds(networks []builtinNetwork, workers int, runs int) (time.Duration, [][]byte, error) {
+	var total time.Duration
+	var outputs [][]byte
+	for run := 0; run < runs; run++ {
+		buildSlots = newBuildSlots(workers)
+		started := time.Now()
+		builds, err := buildNetworks(networks)
+		total += time.Since(started)
+		if err != nil {
+			return 0, nil, err
+		}
+		outputs = buildOutputs(builds)
+	}
+	return total / time.Duration(runs), outputs, nil
+}
+
+func runBenchmarkBuildCommand(args []string) error {
+	flags := flag.NewFlagSet("benchmark-build", flag.ContinueOnError)
+	workers := flags.Int("workers", runtime.NumCPU(), "number of workers of the parallel build")
+	runs := flags.Int("runs", 3, "number of builds to average")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *runs < 1 || *workers < 1 {
+		return fmt.Errorf("number of runs and workers must be positive")
+	}
+	defer func(slots chan struct{}) { buildSlots = slots }(buildSlots)
+	sequential, sequentialOutputs, err := timeNetworkBuilds(builtinNetworks, 1, *runs)
+	if err != nil {
+		return err
+	}
+	parallel, parallelOutputs, err := timeNetworkBuilds(builtinNetworks, *workers, *runs)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%-12s %8s %14s\n", "build", "workers", "avg time")
+	fmt.Printf("%-12s %8d %14s\n", "sequential", 1, sequential.Round(time.Millisecond))
+	fmt.Printf("%-12s %8d %14s\n", "parallel", *workers, parallel.Round(time.Millisecond))
+	fmt.Printf("speedup: %.2fx\n", float64(sequential)/float64(parallel))
+	for i, network := range builtinNetworks {
+		if !bytes.Equal(sequentialOutputs[i], parallelOutputs[i]) {
+			return fmt.Errorf("parallel build of %s differs from sequential build", network.Name)
+		}
+	}
+	fmt.Printf(" + outputs of %d networks are identical\n", len(builtinNetworks))
+	return nil
+}
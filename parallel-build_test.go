@@ -0,0 +1,107 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func withBuildWorkers(t testing.TB, workers int) {
+	slots := buildSlots
+	buildSlots = newBuildSlots(workers)
+	t.Cleanup(func() { buildSlots = slots })
+}
+
+func TestRunParallelNestedLimit(t *testing.T) {
+	for _, workers := range []int{1, 2, 4} {
+		t.Run(fmt.Sprintf("workers=%d", workers), func(t *testing.T) {
+			withBuildWorkers(t, workers)
+			var running, peak int32
+			work := func() {
+				n := atomic.AddInt32(&running, 1)
+				for {
+					p := atomic.LoadInt32(&peak)
+					if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+						break
+					}
+				}
+				time.Sleep(time.Millisecond)
+				atomic.AddInt32(&running, -1)
+			}
+			var done int32
+			err := runParallel(4, func(i int) error {
+				return runParallel(8, func(j int) error {
+					work()
+					atomic.AddInt32(&done, 1)
+					return nil
+				})
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if done != 32 {
+				t.Fatalf("expected 32 tasks to be done, got %d", done)
+			}
+			if int(peak) > workers {
+				t.Fatalf("expected at most %d tasks running at once, got %d", workers, peak)
+			}
+		})
+	}
+}
+
+func TestRunParallelErrorsOrder(t *testing.T) {
+	withBuildWorkers(t, 4)
+	err := runParallel(3, func(i int) error {
+		if i == 1 {
+			panic("bad task")
+		}
+		return fmt.Errorf("task %d failed", i)
+	})
+	expected := "task 0 failed\ntask 1 panicked: bad task\ntask 2 failed"
+	if err == nil || err.Error() != expected {
+		t.Fatalf("expected %q, got %v", expected, err)
+	}
+}
+
+func buildNetworkOutputs(t testing.TB, workers int) [][]byte {
+	withBuildWorkers(t, workers)
+	builds, err := buildNetworks(builtinNetworks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buildOutputs(builds)
+}
+
+func TestBuildNetworksParallelIdentical(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds all networks")
+	}
+	sequential := buildNetworkOutputs(t, 1)
+	parallel := buildNetworkOutputs(t, 8)
+	for i, network := range builtinNetworks {
+		if !bytes.Equal(sequential[i], parallel[i]) {
+			t.Errorf("parallel build of %s differs from sequential build", network.Name)
+		}
+	}
+}
+
+func BenchmarkBuildNetworks(b *testing.B) {
+	for _, bench := range []struct {
+		name    string
+		workers int
+	}{
+		{"sequential", 1},
+		{"parallel", 8},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			withBuildWorkers(b, bench.workers)
+			for i := 0; i < b.N; i++ {
+				if _, err := buildNetworks(builtinNetworks); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/systemcontract"
+)
+
+// viewCallAssertion is a view function call that must succeed on a healthy system contracts state
+type viewCallAssertion struct {
+	Contract systemContract
+	Method   string
+	Args     []interface{}
+	Check    func(result []interface{}) error
+}
+
+type viewCallAssertionResult struct {
+	Contract string `json:"contract"`
+	Method   string `json:"method"`
+	Error    string `json:"error,omitempty"`
+}
+
+func expectNonZero(result []interface{}) error {
+	switch v := result[0].(type) {
+	case *big.Int:
+		if v.Sign() == 0 {
+			return fmt.Errorf("zero value")
+		}
+	case uint32:
+		if v == 0 {
+			return fmt.Errorf("zero value")
+		}
+	case []common.Address:
+		if len(v) == 0 {
+			return fmt.Errorf("empty list")
+		}
+	}
+	return nil
+}
+
+func expectTrue(result []interface{}) error {
+	if !result[0].(bool) {
+		return fmt.Errorf("false")
+	}
+	return nil
+}
+
+func postBuildAssertions() []viewCallAssertion {
+	var result []viewCallAssertion
+	for _, contract := range systemContracts {
+		result = append(result, viewCallAssertion{Contract: contract, Method: "isInitialized", Check: expectTrue})
+	}
+	staking, _ := systemContractByAddress(stakingAddress)
+	chainConfig, _ := systemContractByAddress(chainConfigAddress)
+	governance, _ := systemContractByAddress(governanceAddress)
+	runtimeUpgrade, _ := systemContractByAddress(runtimeUpgradeAddress)
+	tokenomics, _ := systemContractByAddress(tokenomicsAddress)
+	systemReward, _ := systemContractByAddress(systemRewardAddress)
+	return append(result,
+		viewCallAssertion{Contract: staking, Method: "getValidators", Check: expectNonZero},
+		viewCallAssertion{Contract: staking, Method: "currentEpoch"},
+		viewCallAssertion{Contract: chainConfig, Method: "getEpochBlockInterval", Check: expectNonZero},
+		viewCallAssertion{Contract: chainConfig, Method: "getFelonyThreshold", Check: expectNonZero},
+		viewCallAssertion{Contract: chainConfig, Method: "getUndelegatePeriod"},
+		viewCallAssertion{Contract: chainConfig, Method: "getMinValidatorStakeAmount", Check: expectNonZero},
+		viewCallAssertion{Contract: governance, Method: "votingPeriod", Check: expectNonZero},
+		viewCallAssertion{Contract: governance, Method: "getVotingSupply"},
+		viewCallAssertion{Contract: runtimeUpgrade, Method: "getEvmHookAddress", Check: func(result []interface{}) error {
+			if hook := result[0].(common.Address); hook != systemcontract.EvmHookRuntimeUpgradeAddress {
+				return fmt.Errorf("unexpected EVM hook address %s", hook.Hex())
+			}
+			return nil
+		}},
+		viewCallAssertion{Contract: runtimeUpgrade, Method: "getSystemContracts", Check: expectNonZero},
+		viewCallAssertion{Contract: tokenomics, Method: "getTotalSupply", Check: expectNonZero},
+		viewCallAssertion{Contract: systemReward, Method: "getSystemFee"},
+	)
+}
+
+// runViewCallAssertions executes all assertions and returns results for every call (failed or not)
+func runViewCallAssertions(env *simulationEnv, assertions []viewCallAssertion) ([]viewCallAssertionResult, int) {
+	var results []viewCallAssertionResult
+	failed := 0
+	for _, assertion := range assertions {
+		result := viewCallAssertionResult{Contract: assertion.Contract.Name, Method: assertion.Method}
+		values, err := env.staticCall(assertion.Contract.Address, mustParseArtifactABI(assertion.Contract.RawArtifact), assertion.Method, assertion.Args...)
+		if err == nil && assertion.Check != nil {
+			err = assertion.Check(values)
+		}
+		if err != nil {
+			result.Error = err.Error()
+			failed++
+		}
+		results = append(results, result)
+	}
+	return results, failed
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+
+	"github.com/holiman/uint256"
+)
+
+// systemContractDependencies lists system contracts called by the contract (through the injector),
+// dependencies are deployed and initialized first when system contracts share the state
+var systemContractDependencies = map[common.Address][]common.Address{
+	stakingAddress:           {chainConfigAddress},
+	slashingIndicatorAddress: {stakingAddress},
+	stakingPoolAddress:       {stakingAddress, chainConfigAddress},
+	governanceAddress:        {stakingAddress, chainConfigAddress},
+	tokenomicsAddress:        {stakingAddress},
+}
+
+// maxReportedSlots limits number of storage slots listed per difference
+const maxReportedSlots = 5
+
+// sharedStateOrder returns indexes of constructors in dependency order, independent contracts keep constructor order
+func sharedStateOrder(ctors []systemContractCtor) ([]int, error) {
+	index := make(map[common.Address]int, len(ctors))
+	for i, ctor := range ctors {
+		index[ctor.Address] = i
+	}
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	marks := make([]int, len(ctors))
+	var order []int
+	var visit func(i int) error
+	visit = func(i int) error {
+		switch marks[i] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("circular dependency of system contracts (%s)", systemContractName(ctors[i].Address))
+		}
+		marks[i] = visiting
+		for _, dependency := range systemContractDependencies[ctors[i].Address] {
+			if j, ok := index[dependency]; ok {
+				if err := visit(j); err != nil {
+					return err
+				}
+			}
+		}
+		marks[i] = visited
+		order = append(order, i)
+		return nil
+	}
+	for i := range ctors {
+		if err := visit(i); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+// simulateSharedSystemContracts deploys all system contracts into one state in the given order, then calls their init
+// functions against the full system, genesis accounts contain the state after all constructors
+func simulateSharedSystemContracts(header *types.Header, chainConfig *params.ChainConfig, ctors []systemContractCtor, inputs [][]byte, order []int, options ctorSimulationOptions) ([]*systemContractReport, []core.GenesisAccount, error) {
+	statedb, err := newSimulationStateDB(nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, ctor := range ctors {
+		if ctor.Balance != nil {
+			statedb.SetBalance(ctor.Address, uint256.MustFromBig(ctor.Balance))
+		}
+	}
+	reports := make([]*systemContractReport, len(ctors))
+	accounts := make([]core.GenesisAccount, len(ctors))
+	for _, i := range order {
+		artifact, err := parseArtifact(ctors[i].RawArtifact)
+		if err != nil {
+			return nil, nil, err
+		}
+		bytecode := append(hexutil.MustDecode(artifact.Bytecode), inputs[i]...)
+		reports[i] = newSystemContractReport(ctors[i].Address, options.GasLimit, bytecode)
+		evm := newSystemContractEVM(header, chainConfig, statedb, ctors[i].Address, options.GasLimit)
+		if accounts[i].Code, err = deploySystemContract(evm, reports[i], artifact, bytecode, options); err != nil {
+			return nil, nil, err
+		}
+	}
+	// constructors may write into each other, so storage is read once all contracts are deployed
+	for i, ctor := range ctors {
+		accounts[i].Storage = readSystemContractStorage(statedb, ctor.Address)
+		accounts[i].Balance = big.NewInt(0)
+	}
+	for _, i := range order {
+		evm := newSystemContractEVM(header, chainConfig, statedb, ctors[i].Address, options.GasLimit)
+		if err := initSystemContract(evm, reports[i], ctors[i].RawArtifact, options); err != nil {
+			return nil, nil, err
+		}
+	}
+	for i, ctor := range ctors {
+		reports[i].initStorage = readSystemContractStorage(statedb, ctor.Address)
+	}
+	return reports, accounts, nil
+}
+
+// diffStorageSlots returns sorted slots whose values differ, missing slots are zero
+func diffStorageSlots(a state.Storage, b state.Storage) []common.Hash {
+	var slots []common.Hash
+	for slot, value := range a {
+		if b[slot] != value {
+			slots = append(slots, slot)
+		}
+	}
+	for slot, value := range b {
+		if _, ok := a[slot]; !ok && value != (common.Hash{}) {
+			slots = append(slots, slot)
+		}
+	}
+	sort.Slice(slots, func(i, j int) bool {
+		return slots[i].Cmp(slots[j]) < 0
+	})
+	return slots
+}
+
+func formatStorageSlots(slots []common.Hash) string {
+	var result []string
+	for i, slot := range slots {
+		if i == maxReportedSlots {
+			result = append(result, fmt.Sprintf("and %d more", len(slots)-maxReportedSlots))
+			break
+		}
+		result = append(result, slot.Hex())
+	}
+	return strings.Join(result, ", ")
+}
+
+// compareSharedState describes differences of the shared state simulation from the isolated one
+func compareSharedState(shared []*systemContractReport, sharedAccounts []core.GenesisAccount, isolated []*systemContractReport, isolatedAccounts []core.GenesisAccount, isolatedErrs []error) []string {
+	var diffs []string
+	for i, s := range shared {
+		if isolatedErrs[i] != nil {
+			diffs = append(diffs, fmt.Sprintf("%s fails in isolated state: %v", s.Name, isolatedErrs[i]))
+			continue
+		}
+		if slots := diffStorageSlots(sharedAccounts[i].Storage, isolatedAccounts[i].Storage); len(slots) > 0 {
+			diffs = append(diffs, fmt.Sprintf("%s genesis storage differs from isolated state at %d slots: %s", s.Name, len(slots), formatStorageSlots(slots)))
+		}
+		if slots := diffStorageSlots(s.initStorage, isolated[i].initStorage); len(slots) > 0 {
+			diffs = append(diffs, fmt.Sprintf("%s storage after init differs from isolated state at %d slots: %s", s.Name, len(slots), formatStorageSlots(slots)))
+		}
+		if s.CtorGasUsed != isolated[i].CtorGasUsed {
+			diffs = append(diffs, fmt.Sprintf("%s constructor used %d gas (%d in isolated state)", s.Name, s.CtorGasUsed, isolated[i].CtorGasUsed))
+		}
+		if s.InitGasUsed != isolated[i].InitGasUsed {
+			diffs = append(diffs, fmt.Sprintf("%s init used %d gas (%d in isolated state)", s.Name, s.InitGasUsed, isolated[i].InitGasUsed))
+		}
+	}
+	return diffs
+}
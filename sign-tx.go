@@ -0,0 +1,274 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// signedTransaction is a raw signed transaction with its decode for the review
+type signedTransaction struct {
+	Raw      hexutil.Bytes          `json:"raw"`
+	Hash     common.Hash            `json:"hash"`
+	From     common.Address         `json:"from"`
+	To       common.Address         `json:"to"`
+	Contract string                 `json:"contract"`
+	ChainId  *big.Int               `json:"chainId"`
+	Nonce    uint64                 `json:"nonce"`
+	Gas      uint64                 `json:"gas"`
+	GasPrice *big.Int               `json:"gasPrice,omitempty"`
+	MaxFee   *big.Int               `json:"maxFeePerGas,omitempty"`
+	TipCap   *big.Int               `json:"maxPriorityFeePerGas,omitempty"`
+	Value    *big.Int               `json:"value"`
+	Method   string                 `json:"method"`
+	Args     map[string]interface{} `json:"args"`
+}
+
+// readGovernanceProposal reads proposal with targets, values, calldatas and description (upgrade proposals are accepted too)
+func readGovernanceProposal(filePath string) (*governanceProposal, error) {
+	proposal := &governanceProposal{}
+	fileContents, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(fileContents, proposal); err != nil {
+		return nil, fmt.Errorf("failed to parse proposal (%s): %w", filePath, err)
+	}
+	if err := proposal.validate(); err != nil {
+		return nil, fmt.Errorf("bad proposal (%s): %w", filePath, err)
+	}
+	return proposal, nil
+}
+
+// decodeSignedTransaction decodes the raw transaction back, so the review doesn't depend on the inputs of the command
+func decodeSignedTransaction(raw []byte) (*signedTransaction, error) {
+	tx := new(types.Transaction)
+	if err := tx.UnmarshalBinary(raw); err != nil {
+		return nil, err
+	}
+	from, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+	if err != nil {
+		return nil, err
+	}
+	result := &signedTransaction{
+		Raw:     raw,
+		Hash:    tx.Hash(),
+		From:    from,
+		To:      *tx.To(),
+		ChainId: tx.ChainId(),
+		Nonce:   tx.Nonce(),
+		Gas:     tx.Gas(),
+		Value:   tx.Value(),
+		Args:    make(map[string]interface{}),
+	}
+	if tx.Type() == types.DynamicFeeTxType {
+		result.MaxFee, result.TipCap = tx.GasFeeCap(), tx.GasTipCap()
+	} else {
+		result.GasPrice = tx.GasPrice()
+	}
+	contract, ok := systemContractByAddress(result.To)
+	if !ok || len(tx.Data()) < 4 {
+		return result, nil
+	}
+	result.Contract = contract.Name
+	contractABI := mustParseArtifactABI(contract.RawArtifact)
+	method, err := contractABI.MethodById(tx.Data()[:4])
+	if err != nil {
+		return result, nil
+	}
+	result.Method = method.Sig
+	if err := method.Inputs.UnpackIntoMap(result.Args, tx.Data()[4:]); err != nil {
+		return nil, fmt.Errorf("failed to decode %s arguments: %w", method.Name, err)
+	}
+	return result, nil
+}
+
+func printSignedTransaction(w io.Writer, tx *signedTransaction) {
+	fmt.Fprintf(w, "transaction %s\n", tx.Hash.Hex())
+	fmt.Fprintf(w, " ~ from: %s\n", tx.From.Hex())
+	fmt.Fprintf(w, " ~ to: %s (%s)\n", tx.To.Hex(), tx.Contract)
+	fmt.Fprintf(w, " ~ chain id: %s, nonce: %d, gas: %d\n", tx.ChainId, tx.Nonce, tx.Gas)
+	if tx.GasPrice != nil {
+		fmt.Fprintf(w, " ~ gas price: %s wei\n", tx.GasPrice)
+	} else {
+		fmt.Fprintf(w, " ~ max fee: %s wei, priority fee: %s wei\n", tx.MaxFee, tx.TipCap)
+	}
+	fmt.Fprintf(w, " ~ value: %s\n", formatAmount(tx.Value))
+	fmt.Fprintf(w, " ~ method: %s\n", tx.Method)
+	var names []string
+	for name := range tx.Args {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value := tx.Args[name]
+		if b, ok := value.([]byte); ok {
+			value = hexutil.Encode(b)
+		}
+		fmt.Fprintf(w, "    %s: %v\n", name, value)
+	}
+	fmt.Fprintf(w, "%s\n", tx.Raw)
+}
+
+// readPasswordFile reads keystore password, only trailing newline of the file is trimmed, other whitespaces
+// are a part of the password (like in upgrade-runtime.js)
+func readPasswordFile(filePath string) (string, error) {
+	password, err := os.ReadFile(filePath)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(strings.TrimSuffix(string(password), "\n"), "\r"), nil
+}
+
+func runSignTxCommand(args []string) error {
+	flags := flag.NewFlagSet("sign-tx", flag.ContinueOnError)
+	keystoreFile := flags.String("keystore", "", "V3 keystore file of the sender")
+	passwordFile := flags.String("password", "", "file with keystore password")
+	chainId := flags.Int64("chain-id", 0, "chain id of the network")
+	nonce := flags.Uint64("nonce", 0, "nonce of the sender")
+	gasLimit := flags.Uint64("gas", 1_000_000, "gas limit")
+	gasPrice := flags.String("gas-price", "", "gas price in wei (legacy transaction)")
+	maxFee := flags.String("max-fee", "", "max fee per gas in wei (EIP-1559 transaction, overrides -gas-price)")
+	priorityFee := flags.String("priority-fee", "0", "max priority fee per gas in wei (EIP-1559 transaction)")
+	call := flags.String("call", "", "call to sign: propose, castVote, execute or delegate")
+	proposalFile := flags.String("proposal", "", "proposal file for propose and execute")
+	votingPeriod := flags.Uint64("voting-period", 0, "custom voting period for propose (in blocks)")
+	proposalId := flags.String("proposal-id", "", "proposal id for castVote")
+	support := flags.String("support", "for", "vote type for castVote: for, against or abstain")
+	validator := flags.String("validator", "", "validator for delegate")
+	amount := flags.String("amount", "", "amount to delegate in CHZ")
+	format := flags.String("format", "text", "output format: text, json or raw")
+	outputFile := flags.String("output", "stdout", "output file")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *keystoreFile == "" || *passwordFile == "" {
+		return fmt.Errorf("keystore and password files are required")
+	}
+	if *chainId <= 0 {
+		return fmt.Errorf("chain id is required")
+	}
+	// build calldata
+	var to common.Address
+	var input []byte
+	value := big.NewInt(0)
+	var err error
+	switch *call {
+	case "propose", "execute":
+		if *proposalFile == "" {
+			return fmt.Errorf("proposal file is required for %s", *call)
+		}
+		proposal, err := readGovernanceProposal(*proposalFile)
+		if err != nil {
+			return err
+		}
+		governanceABI := mustParseArtifactABI(governanceRawArtifact)
+		values, calldatas := proposal.callArgs()
+		to = governanceAddress
+		switch {
+		case *call == "execute":
+			input, err = governanceABI.Pack("execute", proposal.Targets, values, calldatas, crypto.Keccak256Hash([]byte(proposal.Description)))
+		case *votingPeriod > 0:
+			input, err = governanceABI.Pack("proposeWithCustomVotingPeriod", proposal.Targets, values, calldatas, proposal.Description, new(big.Int).SetUint64(*votingPeriod))
+		default:
+			input, err = governanceABI.Pack("propose", proposal.Targets, values, calldatas, proposal.Description)
+		}
+		if err != nil {
+			return err
+		}
+	case "castVote":
+		id, ok := new(big.Int).SetString(*proposalId, 0)
+		if !ok {
+			return fmt.Errorf("bad proposal id (%s)", *proposalId)
+		}
+		voteType, ok := voteTypes[strings.ToLower(*support)]
+		if !ok {
+			return fmt.Errorf("unknown vote type (%s), must be for, against or abstain", *support)
+		}
+		to = governanceAddress
+		if input, err = mustParseArtifactABI(governanceRawArtifact).Pack("castVote", id, voteType); err != nil {
+			return err
+		}
+	case "delegate":
+		validatorAddress, err := parseChecksumAddress(*validator)
+		if err != nil {
+			return err
+		}
+		if value, err = parseAllocationAmount(*amount); err != nil {
+			return err
+		}
+		to = stakingAddress
+		if input, err = mustParseArtifactABI(stakingRawArtifact).Pack("delegate", validatorAddress); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown call (%s), must be propose, castVote, execute or delegate", *call)
+	}
+	// sign transaction
+	keyJson, err := os.ReadFile(*keystoreFile)
+	if err != nil {
+		return err
+	}
+	password, err := readPasswordFile(*passwordFile)
+	if err != nil {
+		return err
+	}
+	key, err := keystore.DecryptKey(keyJson, password)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt keystore (%s): %w", *keystoreFile, err)
+	}
+	var txData types.TxData
+	if *maxFee != "" {
+		feeCap, ok := new(big.Int).SetString(*maxFee, 10)
+		tipCap, ok2 := new(big.Int).SetString(*priorityFee, 10)
+		if !ok || !ok2 {
+			return fmt.Errorf("bad max fee (%s) or priority fee (%s)", *maxFee, *priorityFee)
+		}
+		txData = &types.DynamicFeeTx{ChainID: big.NewInt(*chainId), Nonce: *nonce, GasTipCap: tipCap, GasFeeCap: feeCap, Gas: *gasLimit, To: &to, Value: value, Data: input}
+	} else {
+		if *gasPrice == "" {
+			return fmt.Errorf("gas price or max fee is required")
+		}
+		price, ok := new(big.Int).SetString(*gasPrice, 10)
+		if !ok {
+			return fmt.Errorf("bad gas price (%s)", *gasPrice)
+		}
+		txData = &types.LegacyTx{Nonce: *nonce, GasPrice: price, Gas: *gasLimit, To: &to, Value: value, Data: input}
+	}
+	tx, err := types.SignNewTx(key.PrivateKey, types.LatestSignerForChainID(big.NewInt(*chainId)), txData)
+	if err != nil {
+		return err
+	}
+	raw, err := tx.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	decoded, err := decodeSignedTransaction(raw)
+	if err != nil {
+		return err
+	}
+	switch *format {
+	case "raw":
+		return writeOutputFile(*outputFile, []byte(hexutil.Encode(raw)+"\n"))
+	case "json":
+		result, _ := json.MarshalIndent(decoded, "", "  ")
+		return writeOutputFile(*outputFile, append(result, '\n'))
+	case "text":
+		var text bytes.Buffer
+		printSignedTransaction(&text, decoded)
+		return writeOutputFile(*outputFile, text.Bytes())
+	}
+	return fmt.Errorf("unknown output format (%s)", *format)
+}
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var proposalStates = []string{"Pending", "Active", "Canceled", "Defeated", "Succeeded", "Queued", "Expired", "Executed"}
+
+var voteTypes = map[string]uint8{"against": 0, "for": 1, "abstain": 2}
+
+type governanceVoter struct {
+	Owner   common.Address `json:"owner"`
+	Support string         `json:"support"`
+}
+
+type governanceStage struct {
+	Name    string          `json:"name"`
+	Account common.Address  `json:"account"`
+	Block   uint64          `json:"block"`
+	Success bool            `json:"success"`
+	Error   string          `json:"error,omitempty"`
+	GasUsed uint64          `json:"gasUsed"`
+	Storage []storageChange `json:"storage,omitempty"`
+}
+
+type governanceSimulationReport struct {
+	ProposalId    *big.Int          `json:"proposalId"`
+	Snapshot      uint64            `json:"snapshot"`
+	Deadline      uint64            `json:"deadline"`
+	Quorum        *big.Int          `json:"quorum"`
+	VotesFor      *big.Int          `json:"votesFor"`
+	VotesAgainst  *big.Int          `json:"votesAgainst"`
+	VotesAbstain  *big.Int          `json:"votesAbstain"`
+	QuorumReached bool              `json:"quorumReached"`
+	FinalState    string            `json:"finalState"`
+	Stages        []governanceStage `json:"stages"`
+	// CodeChanges contains system contracts whose runtime code is changed by the proposal
+	CodeChanges map[string]common.Hash `json:"codeChanges,omitempty"`
+	FailedAt    string                 `json:"failedAt,omitempty"`
+}
+
+// parseGovernanceVoter parses voter in format "0x...[:for|against|abstain]"
+func parseGovernanceVoter(value string) (governanceVoter, error) {
+	parts := strings.SplitN(value, ":", 2)
+	if !common.IsHexAddress(parts[0]) {
+		return governanceVoter{}, fmt.Errorf("bad voter address (%s)", parts[0])
+	}
+	voter := governanceVoter{Owner: common.HexToAddress(parts[0]), Support: "for"}
+	if len(parts) > 1 {
+		voter.Support = strings.ToLower(parts[1])
+	}
+	if _, ok := voteTypes[voter.Support]; !ok {
+		return governanceVoter{}, fmt.Errorf("unknown vote type (%s), must be for, against or abstain", voter.Support)
+	}
+	return voter, nil
+}
+
+// activeValidatorOwners returns owners of the validators from the active validator set
+func activeValidatorOwners(env *simulationEnv) ([]common.Address, error) {
+	stakingABI := mustParseArtifactABI(stakingRawArtifact)
+	result, err := env.staticCall(stakingAddress, stakingABI, "getValidators")
+	if err != nil {
+		return nil, err
+	}
+	var owners []common.Address
+	for _, validator := range result[0].([]common.Address) {
+		status, err := env.staticCall(stakingAddress, stakingABI, "getValidatorStatus", validator)
+		if err != nil {
+			return nil, err
+		}
+		owners = append(owners, status[0].(common.Address))
+	}
+	return owners, nil
+}
+
+func governanceUint64(env *simulationEnv, governanceABI abi.ABI, method string, args ...interface{}) (uint64, error) {
+	result, err := env.staticCall(governanceAddress, governanceABI, method, args...)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", method, err)
+	}
+	return result[0].(*big.Int).Uint64(), nil
+}
+
+// simulateGovernanceProposal runs propose, vote and execute stages, simulation stops at the first failed stage
+func simulateGovernanceProposal(env *simulationEnv, proposal *governanceProposal, proposer common.Address, voters []governanceVoter, votingPeriod uint64) *governanceSimulationReport {
+	governanceABI := mustParseArtifactABI(governanceRawArtifact)
+	report := &governanceSimulationReport{}
+	runStage := func(name string, account common.Address, method string, args ...interface{}) ([]interface{}, bool) {
+		stage := governanceStage{Name: name, Account: account, Block: env.Header.Number.Uint64()}
+		input, err := governanceABI.Pack(method, args...)
+		if err != nil {
+			stage.Error = err.Error()
+		} else {
+			result := env.call(account, governanceAddress, input, nil, simulationGasLimit)
+			stage.GasUsed, stage.Storage = result.GasUsed, sortedStorageChanges(result.Storage)
+			stage.Success = result.Err == nil
+			stage.Error = describeCallError(result.Return, result.Err)
+			if stage.Success {
+				output, _ := governanceABI.Unpack(method, result.Return)
+				report.Stages = append(report.Stages, stage)
+				return output, true
+			}
+		}
+		report.Stages = append(report.Stages, stage)
+		report.FailedAt = name
+		return nil, false
+	}
+	failWith := func(name string, err error) *governanceSimulationReport {
+		report.Stages = append(report.Stages, governanceStage{Name: name, Block: env.Header.Number.Uint64(), Error: err.Error()})
+		report.FailedAt = name
+		return report
+	}
+	values, calldatas := proposal.callArgs()
+	var output []interface{}
+	var ok bool
+	if votingPeriod > 0 {
+		output, ok = runStage("propose", proposer, "proposeWithCustomVotingPeriod", proposal.Targets, values, calldatas, proposal.Description, new(big.Int).SetUint64(votingPeriod))
+	} else {
+		output, ok = runStage("propose", proposer, "propose", proposal.Targets, values, calldatas, proposal.Description)
+	}
+	if !ok {
+		return report
+	}
+	report.ProposalId = output[0].(*big.Int)
+	var err error
+	if report.Snapshot, err = governanceUint64(env, governanceABI, "proposalSnapshot", report.ProposalId); err != nil {
+		return failWith("propose", err)
+	}
+	if report.Deadline, err = governanceUint64(env, governanceABI, "proposalDeadline", report.ProposalId); err != nil {
+		return failWith("propose", err)
+	}
+	// voting starts right after the snapshot block
+	if current := env.Header.Number.Uint64(); current <= report.Snapshot {
+		env.advanceBlocks(report.Snapshot - current + 1)
+	}
+	for _, voter := range voters {
+		if _, ok := runStage("castVote", voter.Owner, "castVote", report.ProposalId, voteTypes[voter.Support]); !ok {
+			return report
+		}
+	}
+	votes, err := env.staticCall(governanceAddress, governanceABI, "proposalVotes", report.ProposalId)
+	if err != nil {
+		return failWith("tally", err)
+	}
+	report.VotesAgainst, report.VotesFor, report.VotesAbstain = votes[0].(*big.Int), votes[1].(*big.Int), votes[2].(*big.Int)
+	quorum, err := env.staticCall(governanceAddress, governanceABI, "quorum", new(big.Int).SetUint64(report.Snapshot))
+	if err != nil {
+		return failWith("tally", err)
+	}
+	report.Quorum = quorum[0].(*big.Int)
+	report.QuorumReached = new(big.Int).Add(report.VotesFor, report.VotesAbstain).Cmp(report.Quorum) >= 0
+	// move past the voting period
+	if current := env.Header.Number.Uint64(); current <= report.Deadline {
+		env.advanceBlocks(report.Deadline - current + 1)
+	}
+	state, err := env.staticCall(governanceAddress, governanceABI, "state", report.ProposalId)
+	if err != nil {
+		return failWith("state", err)
+	}
+	report.FinalState = proposalStates[state[0].(uint8)]
+	if report.FinalState != "Succeeded" {
+		return failWith("state", fmt.Errorf("proposal is %s after the voting period", report.FinalState))
+	}
+	codeHashes := make(map[common.Address]common.Hash)
+	for _, c := range systemContracts {
+		codeHashes[c.Address] = crypto.Keccak256Hash(env.StateDB.GetCode(c.Address))
+	}
+	if _, ok := runStage("execute", proposer, "execute", proposal.Targets, values, calldatas, crypto.Keccak256Hash([]byte(proposal.Description))); !ok {
+		return report
+	}
+	for _, c := range systemContracts {
+		if newHash := crypto.Keccak256Hash(env.StateDB.GetCode(c.Address)); newHash != codeHashes[c.Address] {
+			if report.CodeChanges == nil {
+				report.CodeChanges = make(map[string]common.Hash)
+			}
+			report.CodeChanges[c.Name] = newHash
+		}
+	}
+	if state, err = env.staticCall(governanceAddress, governanceABI, "state", report.ProposalId); err != nil {
+		return failWith("state", err)
+	}
+	report.FinalState = proposalStates[state[0].(uint8)]
+	return report
+}
+
+func printGovernanceSimulationReport(report *governanceSimulationReport) {
+	for _, stage := range report.Stages {
+		if stage.Success {
+			fmt.Printf(" + %s by %s at block %d: gas used %d\n", stage.Name, stage.Account.Hex(), stage.Block, stage.GasUsed)
+		} else {
+			fmt.Printf(" - %s by %s at block %d failed: %s\n", stage.Name, stage.Account.Hex(), stage.Block, stage.Error)
+		}
+		for _, c := range stage.Storage {
+			fmt.Printf("    ~ %s slot %s: %s -> %s\n", c.Address.Hex(), c.Slot.Hex(), c.Old.Hex(), c.New.Hex())
+		}
+	}
+	if report.ProposalId != nil {
+		fmt.Printf("proposal %s: snapshot=%d deadline=%d\n", report.ProposalId.String(), report.Snapshot, report.Deadline)
+	}
+	if report.Quorum != nil {
+		fmt.Printf("votes: for=%s against=%s abstain=%s quorum=%s reached=%v\n", report.VotesFor, report.VotesAgainst, report.VotesAbstain, report.Quorum, report.QuorumReached)
+	}
+	for _, c := range systemContracts {
+		if codeHash, ok := report.CodeChanges[c.Name]; ok {
+			fmt.Printf("code of %s is changed to %s\n", c.Name, codeHash.Hex())
+		}
+	}
+	if report.FinalState != "" {
+		fmt.Printf("final state: %s\n", report.FinalState)
+	}
+	if report.FailedAt != "" {
+		fmt.Printf("lifecycle failed at: %s\n", report.FailedAt)
+	}
+}
+
+func runSimulateGovernanceCommand(args []string) error {
+	flags := flag.NewFlagSet("simulate-governance", flag.ContinueOnError)
+	stateFile := flags.String("state", "", "genesis file or state dump of the target network")
+	genesisFile := flags.String("genesis", "", "genesis file with chain config (required for state dumps)")
+	stateRoot := flags.String("state-root", "", "expected state root of the state dump")
+	proposalFile := flags.String("proposal", "", "proposal file with targets, values, calldatas and description")
+	proposerFlag := flags.String("proposer", "", "account that creates and executes the proposal (first voter by default)")
+	blockNumber := flags.Uint64("block", 1, "block number where the proposal is created")
+	votingPeriod := flags.Uint64("voting-period", 0, "custom voting period, network's voting period is used if zero")
+	format := flags.String("format", "text", "report format: text or json")
+	var voterFlags, includes stringListFlag
+	flags.Var(&voterFlags, "voter", "validator owner that votes, format is 0x...[:for|against|abstain] (all active validator owners vote for by default)")
+	flags.Var(&includes, "include", "account of the state dump to load besides system contracts (can be repeated)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *stateFile == "" || *proposalFile == "" {
+		return fmt.Errorf("state and proposal files are required")
+	}
+	proposal, err := readGovernanceProposal(*proposalFile)
+	if err != nil {
+		return err
+	}
+	var voters []governanceVoter
+	for _, value := range voterFlags {
+		voter, err := parseGovernanceVoter(value)
+		if err != nil {
+			return err
+		}
+		voters = append(voters, voter)
+	}
+	include, err := parseAddressList(includes)
+	if err != nil {
+		return err
+	}
+	source, err := loadSimulationState(*stateFile, include, common.HexToHash(*stateRoot), false)
+	if err != nil {
+		return err
+	}
+	genesis, err := readChainConfig(source, *genesisFile)
+	if err != nil {
+		return err
+	}
+	env, err := newSimulationEnv(genesis.Config, source.Alloc, *blockNumber, genesis.Timestamp+*blockNumber*simulationBlockPeriod(genesis.Config))
+	if err != nil {
+		return err
+	}
+	if err := env.initSystemContracts(); err != nil {
+		return err
+	}
+	if len(voters) == 0 {
+		owners, err := activeValidatorOwners(env)
+		if err != nil {
+			return err
+		}
+		for _, owner := range owners {
+			voters = append(voters, governanceVoter{Owner: owner, Support: "for"})
+		}
+	}
+	var proposer common.Address
+	if *proposerFlag != "" {
+		if !common.IsHexAddress(*proposerFlag) {
+			return fmt.Errorf("bad proposer address (%s)", *proposerFlag)
+		}
+		proposer = common.HexToAddress(*proposerFlag)
+	} else if len(voters) > 0 {
+		proposer = voters[0].Owner
+	} else {
+		return fmt.Errorf("there are no voters, specify proposer explicitly")
+	}
+	report := simulateGovernanceProposal(env, proposal, proposer, voters, *votingPeriod)
+	switch *format {
+	case "json":
+		result, _ := json.MarshalIndent(report, "", "  ")
+		os.Stdout.Write(append(result, '\n'))
+	case "text":
+		printGovernanceSimulationReport(report)
+	default:
+		return fmt.Errorf("unknown report format (%s)", *format)
+	}
+	if report.FailedAt != "" {
+		return fmt.Errorf("proposal lifecycle failed at %s stage", report.FailedAt)
+	}
+	return nil
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+type storageChange struct {
+	Address common.Address `json:"address"`
+	Slot    common.Hash    `json:"slot"`
+	Old     common.Hash    `json:"old"`
+	New     common.Hash    `json:"new"`
+}
+
+type upgradeSimulationStep struct {
+	Contract string          `json:"contract"`
+	Address  common.Address  `json:"address"`
+	Success  bool            `json:"success"`
+	Error    string          `json:"error,omitempty"`
+	GasUsed  uint64          `json:"gasUsed"`
+	CodeHash common.Hash     `json:"codeHash"`
+	Storage  []storageChange `json:"storage"`
+}
+
+type upgradeSimulationReport struct {
+	BlockNumber uint64                    `json:"blockNumber"`
+	Steps       []upgradeSimulationStep   `json:"steps"`
+	Assertions  []viewCallAssertionResult `json:"assertions"`
+}
+
+// sortedStorageChanges flattens storage changes of the call in a stable order
+func sortedStorageChanges(changes map[common.Address]map[common.Hash][2]common.Hash) []storageChange {
+	var result []storageChange
+	for address, slots := range changes {
+		for slot, values := range slots {
+			result = append(result, storageChange{Address: address, Slot: slot, Old: values[0], New: values[1]})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if c := bytes.Compare(result[i].Address.Bytes(), result[j].Address.Bytes()); c != 0 {
+			return c < 0
+		}
+		return bytes.Compare(result[i].Slot.Bytes(), result[j].Slot.Bytes()) < 0
+	})
+	return result
+}
+
+// readChainConfig returns chain config of the state source, state dumps don't have it so genesis file must be provided
+func readChainConfig(source *stateSource, genesisFile string) (*core.Genesis, error) {
+	if genesisFile != "" {
+		genesisSource, err := readStateSource(genesisFile)
+		if err != nil {
+			return nil, err
+		}
+		if genesisSource.Genesis == nil {
+			return nil, fmt.Errorf("file (%s) is not a genesis file", genesisFile)
+		}
+		return genesisSource.Genesis, nil
+	}
+	if source.Genesis == nil {
+		return nil, fmt.Errorf("chain config is not available in the state dump, specify genesis file")
+	}
+	return source.Genesis, nil
+}
+
+// simulateUpgradeProposal executes proposal calls from the governance address, execution stops at the first failed call
+func simulateUpgradeProposal(env *simulationEnv, proposal *upgradeProposal, gasLimit uint64) (*upgradeSimulationReport, bool) {
+	report := &upgradeSimulationReport{BlockNumber: env.Header.Number.Uint64()}
+	success := true
+	for i, calldata := range proposal.Calldatas {
+		step := upgradeSimulationStep{Contract: systemContractName(proposal.Targets[i]), Address: proposal.Targets[i]}
+		// upgraded contract is decoded from the call, so steps don't depend on order of upgrade entries
+		contract, newCode, isUpgrade := decodeUpgradeCall(proposal.Targets[i], calldata)
+		if isUpgrade {
+			step.Contract, step.Address = systemContractName(contract), contract
+		}
+		result := env.call(governanceAddress, proposal.Targets[i], calldata, (*big.Int)(proposal.Values[i]), gasLimit)
+		step.GasUsed = result.GasUsed
+		step.Storage = sortedStorageChanges(result.Storage)
+		step.CodeHash = crypto.Keccak256Hash(env.StateDB.GetCode(step.Address))
+		step.Success = result.Err == nil
+		if result.Err != nil {
+			step.Error = describeCallError(result.Return, result.Err)
+		} else if isUpgrade && step.CodeHash != crypto.Keccak256Hash(newCode) {
+			step.Success = false
+			step.Error = fmt.Sprintf("runtime code is not replaced by EVM hook, code hash is %s", step.CodeHash.Hex())
+		}
+		report.Steps = append(report.Steps, step)
+		if !step.Success {
+			success = false
+			break
+		}
+	}
+	if !success {
+		return report, false
+	}
+	var failed int
+	report.Assertions, failed = runViewCallAssertions(env, postBuildAssertions())
+	return report, failed == 0
+}
+
+func printUpgradeSimulationReport(report *upgradeSimulationReport) {
+	fmt.Printf("simulating runtime upgrade at block %d\n", report.BlockNumber)
+	for _, step := range report.Steps {
+		if step.Success {
+			fmt.Printf(" + %s (%s): gas used %d, code hash %s\n", step.Contract, step.Address.Hex(), step.GasUsed, step.CodeHash.Hex())
+		} else {
+			fmt.Printf(" - %s (%s): gas used %d, failed: %s\n", step.Contract, step.Address.Hex(), step.GasUsed, step.Error)
+		}
+		for _, c := range step.Storage {
+			fmt.Printf("    ~ %s slot %s: %s -> %s\n", c.Address.Hex(), c.Slot.Hex(), c.Old.Hex(), c.New.Hex())
+		}
+	}
+	for _, a := range report.Assertions {
+		if a.Error == "" {
+			fmt.Printf(" + %s.%s()\n", a.Contract, a.Method)
+		} else {
+			fmt.Printf(" - %s.%s(): %s\n", a.Contract, a.Method, a.Error)
+		}
+	}
+}
+
+func runSimulateUpgradeCommand(args []string) error {
+	flags := flag.NewFlagSet("simulate-upgrade", flag.ContinueOnError)
+	stateFile := flags.String("state", "", "genesis file or state dump of the target network")
+	genesisFile := flags.String("genesis", "", "genesis file with chain config (required for state dumps)")
+	proposalFile := flags.String("proposal", "", "proposal created by upgrade-proposal command (built from artifacts if not specified)")
+	artifactsDir := flags.String("artifacts", "", "forge output directory with new artifacts (embedded artifacts are used by default)")
+	blockNumber := flags.Uint64("block", 1, "block number used for the simulation")
+	gasLimit := flags.Uint64("gas", simulationGasLimit, "gas limit for every proposal call")
+	format := flags.String("format", "text", "report format: text or json")
+	skipLayoutCheck := flags.Bool("skip-layout-check", false, "don't verify storage layout compatibility (unsafe)")
+	stateRoot := flags.String("state-root", "", "expected state root of the state dump")
+	allAccounts := flags.Bool("all-accounts", false, "load all accounts of the state dump (only system contracts and included accounts by default)")
+	var migrationCalls, onlyContracts, knownDirs, includes stringListFlag
+	flags.Var(&includes, "include", "account of the state dump to load besides system contracts (can be repeated)")
+	flags.Var(&migrationCalls, "migration", "migration call for the contract, e.g. ChainConfig=initEpochParams() (can be repeated)")
+	flags.Var(&onlyContracts, "contract", "upgrade only specified system contract (can be repeated)")
+	flags.Var(&knownDirs, "known", "forge output directory of the previous release (can be repeated)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *stateFile == "" {
+		return fmt.Errorf("state file is required")
+	}
+	include, err := parseAddressList(includes)
+	if err != nil {
+		return err
+	}
+	source, err := loadSimulationState(*stateFile, include, common.HexToHash(*stateRoot), *allAccounts)
+	if err != nil {
+		return err
+	}
+	genesis, err := readChainConfig(source, *genesisFile)
+	if err != nil {
+		return err
+	}
+	proposal := &upgradeProposal{}
+	if *proposalFile != "" {
+		fileContents, err := os.ReadFile(*proposalFile)
+		if err != nil {
+			return err
+		}
+		if err := json.Unmarshal(fileContents, proposal); err != nil {
+			return fmt.Errorf("failed to parse proposal (%s): %w", *proposalFile, err)
+		}
+		// proposal may be edited by hand, so calls must be checked before they're indexed
+		if err := proposal.validate(); err != nil {
+			return fmt.Errorf("bad proposal (%s): %w", *proposalFile, err)
+		}
+		if err := proposal.validateUpgrades(); err != nil {
+			return fmt.Errorf("bad proposal (%s): %w", *proposalFile, err)
+		}
+	} else {
+		migrations := make(map[common.Address][]byte)
+		for _, value := range migrationCalls {
+			contract, input, err := parseMigrationCall(value)
+			if err != nil {
+				return err
+			}
+			migrations[contract.Address] = input
+		}
+		proposal, err = createUpgradeProposal(source, upgradeProposalConfig{
+			ArtifactsDir:    *artifactsDir,
+			Migrations:      migrations,
+			OnlyContracts:   onlyContracts,
+			KnownDirs:       knownDirs,
+			SkipLayoutCheck: *skipLayoutCheck,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if len(proposal.Calldatas) == 0 {
+		return fmt.Errorf("proposal is empty, nothing to simulate")
+	}
+	env, err := newSimulationEnv(genesis.Config, source.Alloc, *blockNumber, genesis.Timestamp+*blockNumber*simulationBlockPeriod(genesis.Config))
+	if err != nil {
+		return err
+	}
+	if err := env.initSystemContracts(); err != nil {
+		return err
+	}
+	report, success := simulateUpgradeProposal(env, proposal, *gasLimit)
+	switch *format {
+	case "json":
+		result, _ := json.MarshalIndent(report, "", "  ")
+		os.Stdout.Write(append(result, '\n'))
+	case "text":
+		printUpgradeSimulationReport(report)
+	default:
+		return fmt.Errorf("unknown report format (%s)", *format)
+	}
+	if !success {
+		return fmt.Errorf("runtime upgrade simulation failed")
+	}
+	return nil
+}
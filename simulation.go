@@ -0,0 +1,187 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/triedb"
+
+	"github.com/holiman/uint256"
+)
+
+const simulationGasLimit = 100_000_000
+
+// simulationEnv is an in-memory chain state used to execute system contract calls w/o running a node
+type simulationEnv struct {
+	Config  *params.ChainConfig
+	StateDB *state.StateDB
+	Header  *types.Header
+}
+
+// simulationCallResult is a result of the top-level call, every call is treated as a separate transaction
+type simulationCallResult struct {
+	Return  []byte
+	GasUsed uint64
+	Err     error
+	// Storage contains slots modified by the call (old and new values)
+	Storage map[common.Address]map[common.Hash][2]common.Hash
+}
+
+func newSimulationStateDB(alloc core.GenesisAlloc) (*state.StateDB, error) {
+	ethdb := rawdb.NewDatabase(memorydb.New())
+	db := state.NewDatabaseWithConfig(ethdb, &triedb.Config{})
+	statedb, err := state.New(common.Hash{}, db, nil)
+	if err != nil {
+		return nil, err
+	}
+	for address, account := range alloc {
+		if account.Balance != nil {
+			statedb.SetBalance(address, uint256.MustFromBig(account.Balance))
+		}
+		statedb.SetNonce(address, account.Nonce)
+		if len(account.Code) > 0 {
+			statedb.SetCode(address, account.Code)
+		}
+		for slot, value := range account.Storage {
+			statedb.SetState(address, slot, value)
+		}
+	}
+	statedb.Finalise(false)
+	return statedb, nil
+}
+
+func newSimulationEnv(chainConfig *params.ChainConfig, alloc core.GenesisAlloc, blockNumber uint64, blockTime uint64) (*simulationEnv, error) {
+	statedb, err := newSimulationStateDB(alloc)
+	if err != nil {
+		return nil, err
+	}
+	return &simulationEnv{
+		Config:  chainConfig,
+		StateDB: statedb,
+		Header: &types.Header{
+			Number:     new(big.Int).SetUint64(blockNumber),
+			Time:       blockTime,
+			GasLimit:   simulationGasLimit,
+			Difficulty: big.NewInt(1),
+			BaseFee:    big.NewInt(0),
+		},
+	}, nil
+}
+
+// simulationBlockPeriod returns block time from the parlia config
+func simulationBlockPeriod(config *params.ChainConfig) uint64 {
+	if config.Parlia != nil && config.Parlia.Period > 0 {
+		return config.Parlia.Period
+	}
+	return 3
+}
+
+// advanceBlocks moves chain head forward
+func (env *simulationEnv) advanceBlocks(blocks uint64) {
+	env.Header.Number = new(big.Int).Add(env.Header.Number, new(big.Int).SetUint64(blocks))
+	env.Header.Time += blocks * simulationBlockPeriod(env.Config)
+}
+
+func (env *simulationEnv) newEVM(from common.Address, gasPrice *big.Int) *vm.EVM {
+	blockContext := core.NewEVMBlockContext(env.Header, &dummyChainContext{}, &env.Header.Coinbase)
+	txContext := vm.TxContext{Origin: from, GasPrice: gasPrice}
+	return vm.NewEVM(blockContext, txContext, env.StateDB, env.Config, vm.Config{})
+}
+
+// call executes message call and finalises state as if it was a transaction
+func (env *simulationEnv) call(from common.Address, to common.Address, input []byte, value *big.Int, gasLimit uint64) *simulationCallResult {
+	if value == nil {
+		value = big.NewInt(0)
+	}
+	evm := env.newEVM(from, big.NewInt(0))
+	ret, leftOverGas, err := evm.Call(vm.AccountRef(from), to, input, gasLimit, uint256.MustFromBig(value))
+	result := &simulationCallResult{
+		Return:  ret,
+		GasUsed: gasLimit - leftOverGas,
+		Err:     err,
+		Storage: make(map[common.Address]map[common.Hash][2]common.Hash),
+	}
+	if err == nil {
+		// we track storage changes of system contracts and the callee only
+		watched := []common.Address{to}
+		for _, c := range systemContracts {
+			if c.Address != to {
+				watched = append(watched, c.Address)
+			}
+		}
+		for _, address := range watched {
+			if !env.StateDB.Exist(address) {
+				continue
+			}
+			for slot, newValue := range readDirtyStorageFromState(env.StateDB.GetOrNewStateObject(address)) {
+				oldValue := env.StateDB.GetCommittedState(address, slot)
+				if oldValue == newValue {
+					continue
+				}
+				if result.Storage[address] == nil {
+					result.Storage[address] = make(map[common.Hash][2]common.Hash)
+				}
+				result.Storage[address][slot] = [2]common.Hash{oldValue, newValue}
+			}
+		}
+	}
+	env.StateDB.Finalise(true)
+	return result
+}
+
+// staticCall executes view function w/o modifying the state
+func (env *simulationEnv) staticCall(to common.Address, contractABI abi.ABI, method string, args ...interface{}) ([]interface{}, error) {
+	input, err := contractABI.Pack(method, args...)
+	if err != nil {
+		return nil, err
+	}
+	evm := env.newEVM(common.Address{}, big.NewInt(0))
+	ret, _, err := evm.StaticCall(vm.AccountRef(common.Address{}), to, input, simulationGasLimit)
+	if err != nil {
+		return nil, errors.New(describeCallError(ret, err))
+	}
+	return contractABI.Unpack(method, ret)
+}
+
+// initSystemContracts invokes init function for all system contracts that are not initialized yet (as consensus engine does for the first block)
+func (env *simulationEnv) initSystemContracts() error {
+	injectorABI := mustParseArtifactABI(stakingRawArtifact)
+	for _, contract := range systemContracts {
+		if len(env.StateDB.GetCode(contract.Address)) == 0 {
+			continue
+		}
+		result, err := env.staticCall(contract.Address, injectorABI, "isInitialized")
+		if err != nil {
+			return fmt.Errorf("failed to check %s initialization: %w", contract.Name, err)
+		}
+		if result[0].(bool) {
+			continue
+		}
+		input, _ := injectorABI.Pack("init")
+		if r := env.call(common.Address{}, contract.Address, input, nil, simulationGasLimit); r.Err != nil {
+			return fmt.Errorf("failed to init %s: %s", contract.Name, describeCallError(r.Return, r.Err))
+		}
+	}
+	return nil
+}
+
+// describeCallError decodes revert reason from the return data if possible
+func describeCallError(ret []byte, err error) string {
+	if err == nil {
+		return ""
+	}
+	if reason, unpackErr := abi.UnpackRevert(ret); unpackErr == nil {
+		return fmt.Sprintf("%v: %s", err, reason)
+	}
+	return err.Error()
+}
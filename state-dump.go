@@ -0,0 +1,289 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// dumpAccount is an account entry of "geth dump" or "debug_dumpBlock" output
+type dumpAccount struct {
+	Balance  string            `json:"balance"`
+	Nonce    uint64            `json:"nonce"`
+	Root     hexutil.Bytes     `json:"root"`
+	CodeHash hexutil.Bytes     `json:"codeHash"`
+	Code     hexutil.Bytes     `json:"code,omitempty"`
+	Storage  map[string]string `json:"storage,omitempty"`
+	Address  *common.Address   `json:"address,omitempty"`
+	// AddressHash is set for accounts w/o address preimage, such accounts are keyed as "pre(<hash>)"
+	AddressHash hexutil.Bytes `json:"key,omitempty"`
+}
+
+type stateDump struct {
+	Root     string                 `json:"root"`
+	Accounts map[string]dumpAccount `json:"accounts"`
+}
+
+// stateSource is a genesis file or a state dump converted into the genesis alloc format
+type stateSource struct {
+	Genesis *core.Genesis
+	Alloc   core.GenesisAlloc
+	// Root is a state root declared by the state dump (empty for genesis files)
+	Root common.Hash
+	// Unresolved is a number of dump accounts skipped since their address preimages are unknown
+	Unresolved int
+}
+
+func parseDumpBalance(value string) (*big.Int, error) {
+	if value == "" {
+		return big.NewInt(0), nil
+	}
+	if strings.HasPrefix(value, "0x") || strings.HasPrefix(value, "0X") {
+		return hexutil.DecodeBig(value)
+	}
+	result, ok := new(big.Int).SetString(value, 10)
+	if !ok {
+		return nil, fmt.Errorf("failed to parse balance (%s)", value)
+	}
+	return result, nil
+}
+
+// knownStateAccounts are system contracts and intermediary system address, they're resolved by hash if dump has no preimage
+func knownStateAccounts() []common.Address {
+	known := []common.Address{intermediarySystemAddress}
+	for _, c := range systemContracts {
+		known = append(known, c.Address)
+	}
+	return known
+}
+
+// toAlloc converts dump accounts into alloc, accounts w/o address preimages are restored only if their hash matches
+// one of the known addresses, other accounts are skipped and counted
+func (d *stateDump) toAlloc(known []common.Address) (core.GenesisAlloc, int, error) {
+	byHash := make(map[common.Hash]common.Address, len(known))
+	for _, address := range known {
+		byHash[crypto.Keccak256Hash(address.Bytes())] = address
+	}
+	alloc := make(core.GenesisAlloc, len(d.Accounts))
+	unresolved := 0
+	for key, account := range d.Accounts {
+		var address common.Address
+		if account.Address != nil {
+			address = *account.Address
+		} else if common.IsHexAddress(key) {
+			address = common.HexToAddress(key)
+		} else if a, ok := byHash[common.BytesToHash(account.AddressHash)]; ok && len(account.AddressHash) == common.HashLength {
+			address = a
+		} else {
+			unresolved++
+			continue
+		}
+		balance, err := parseDumpBalance(account.Balance)
+		if err != nil {
+			return nil, 0, fmt.Errorf("account (%s): %w", address.Hex(), err)
+		}
+		var storage map[common.Hash]common.Hash
+		if len(account.Storage) > 0 {
+			storage = make(map[common.Hash]common.Hash, len(account.Storage))
+			for slot, value := range account.Storage {
+				// geth writes storage values w/o 0x prefix and w/o leading zeroes
+				storage[common.HexToHash(slot)] = common.HexToHash(value)
+			}
+		}
+		alloc[address] = core.GenesisAccount{
+			Code:    account.Code,
+			Storage: storage,
+			Balance: balance,
+			Nonce:   account.Nonce,
+		}
+	}
+	return alloc, unresolved, nil
+}
+
+// readStateSource reads genesis file or state dump, format is detected by the presence of "alloc" or "accounts" fields
+func readStateSource(filePath string) (*stateSource, error) {
+	return readStateSourceResolving(filePath, nil)
+}
+
+// readStateSourceResolving reads state like readStateSource, dump accounts w/o preimages are also resolved by the given addresses
+func readStateSourceResolving(filePath string, resolve []common.Address) (*stateSource, error) {
+	fileContents, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	var probe struct {
+		Alloc    json.RawMessage `json:"alloc"`
+		Accounts json.RawMessage `json:"accounts"`
+	}
+	if err := json.Unmarshal(fileContents, &probe); err != nil {
+		return nil, fmt.Errorf("failed to parse state file (%s): %w", filePath, err)
+	}
+	switch {
+	case probe.Alloc != nil:
+		genesis := &core.Genesis{}
+		if err := json.Unmarshal(fileContents, genesis); err != nil {
+			return nil, fmt.Errorf("failed to parse genesis file (%s): %w", filePath, err)
+		}
+		return &stateSource{Genesis: genesis, Alloc: genesis.Alloc}, nil
+	case probe.Accounts != nil:
+		dump := &stateDump{}
+		if err := json.Unmarshal(fileContents, dump); err != nil {
+			return nil, fmt.Errorf("failed to parse state dump (%s): %w", filePath, err)
+		}
+		alloc, unresolved, err := dump.toAlloc(append(knownStateAccounts(), resolve...))
+		if err != nil {
+			return nil, err
+		}
+		return &stateSource{Alloc: alloc, Root: common.HexToHash(dump.Root), Unresolved: unresolved}, nil
+	}
+	return nil, fmt.Errorf("file (%s) is neither genesis nor state dump", filePath)
+}
+
+// computeStateRoot calculates state root of the alloc, empty accounts are kept since they exist in genesis and dumped tries
+func computeStateRoot(alloc core.GenesisAlloc) (common.Hash, error) {
+	statedb, err := newSimulationStateDB(alloc)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return statedb.IntermediateRoot(false), nil
+}
+
+// filterAlloc keeps system contracts, intermediary system address and the specified accounts only
+func filterAlloc(alloc core.GenesisAlloc, include []common.Address) core.GenesisAlloc {
+	keep := []common.Address{intermediarySystemAddress}
+	for _, c := range systemContracts {
+		keep = append(keep, c.Address)
+	}
+	keep = append(keep, include...)
+	result := make(core.GenesisAlloc)
+	for _, address := range keep {
+		if account, ok := alloc[address]; ok {
+			result[address] = account
+		}
+	}
+	return result
+}
+
+// loadSimulationState reads genesis or state dump and verifies its state root (if expected root is specified or dump declares it),
+// state dumps are filtered to the system contracts and included accounts unless all accounts are requested
+func loadSimulationState(filePath string, include []common.Address, expectedRoot common.Hash, allAccounts bool) (*stateSource, error) {
+	source, err := readStateSourceResolving(filePath, include)
+	if err != nil {
+		return nil, err
+	}
+	if source.Unresolved > 0 {
+		// state root is calculated over all accounts, so it can't be checked if some of them are skipped
+		if expectedRoot != (common.Hash{}) {
+			return nil, fmt.Errorf("state root can't be verified, %d accounts of the dump have no address preimage", source.Unresolved)
+		}
+		if allAccounts {
+			return nil, fmt.Errorf("all accounts are requested, but %d accounts of the dump have no address preimage", source.Unresolved)
+		}
+		fmt.Fprintf(os.Stderr, "WARN: %d accounts of the dump have no address preimage, they're skipped and state root (%s) isn't verified\n", source.Unresolved, source.Root.Hex())
+	} else if expectedRoot != (common.Hash{}) || source.Root != (common.Hash{}) {
+		root, err := computeStateRoot(source.Alloc)
+		if err != nil {
+			return nil, err
+		}
+		if source.Root != (common.Hash{}) && root != source.Root {
+			return nil, fmt.Errorf("state root mismatch, dump declares %s but accounts produce %s (is storage missing in the dump?)", source.Root.Hex(), root.Hex())
+		}
+		if expectedRoot != (common.Hash{}) && root != expectedRoot {
+			return nil, fmt.Errorf("state root mismatch, expected %s but got %s", expectedRoot.Hex(), root.Hex())
+		}
+		source.Root = root
+	}
+	for _, address := range include {
+		if _, ok := source.Alloc[address]; !ok {
+			return nil, fmt.Errorf("account (%s) is not found in the state", address.Hex())
+		}
+	}
+	if source.Genesis == nil && !allAccounts {
+		source.Alloc = filterAlloc(source.Alloc, include)
+	}
+	return source, nil
+}
+
+// toDump converts alloc back to the state dump format, root is recalculated since alloc might be filtered
+func (s *stateSource) toDump() (*stateDump, error) {
+	root, err := computeStateRoot(s.Alloc)
+	if err != nil {
+		return nil, err
+	}
+	dump := &stateDump{Root: root.Hex(), Accounts: make(map[string]dumpAccount, len(s.Alloc))}
+	for address, account := range s.Alloc {
+		address := address
+		balance := "0"
+		if account.Balance != nil {
+			balance = account.Balance.String()
+		}
+		var storage map[string]string
+		if len(account.Storage) > 0 {
+			storage = make(map[string]string, len(account.Storage))
+			for slot, value := range account.Storage {
+				storage[slot.Hex()] = value.Hex()
+			}
+		}
+		dump.Accounts[address.Hex()] = dumpAccount{
+			Balance:  balance,
+			Nonce:    account.Nonce,
+			CodeHash: crypto.Keccak256(account.Code),
+			Code:     account.Code,
+			Storage:  storage,
+			Address:  &address,
+		}
+	}
+	return dump, nil
+}
+
+func runImportStateCommand(args []string) error {
+	flags := flag.NewFlagSet("import-state", flag.ContinueOnError)
+	dumpFile := flags.String("dump", "", "output of geth dump or debug_dumpBlock")
+	stateRoot := flags.String("state-root", "", "expected state root of the dump")
+	outputFile := flags.String("output", "stdout", "output file for the filtered state")
+	var includes stringListFlag
+	flags.Var(&includes, "include", "account to keep besides system contracts (can be repeated)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *dumpFile == "" {
+		return fmt.Errorf("dump file is required")
+	}
+	include, err := parseAddressList(includes)
+	if err != nil {
+		return err
+	}
+	source, err := loadSimulationState(*dumpFile, include, common.HexToHash(*stateRoot), false)
+	if err != nil {
+		return err
+	}
+	if source.Genesis != nil {
+		return fmt.Errorf("file (%s) is a genesis file, not a state dump", *dumpFile)
+	}
+	fmt.Fprintf(os.Stderr, "imported %d accounts, source state root is %s\n", len(source.Alloc), source.Root.Hex())
+	dump, err := source.toDump()
+	if err != nil {
+		return err
+	}
+	result, _ := json.MarshalIndent(dump, "", "  ")
+	return writeOutputFile(*outputFile, append(result, '\n'))
+}
+
+func parseAddressList(values []string) ([]common.Address, error) {
+	var result []common.Address
+	for _, value := range values {
+		if !common.IsHexAddress(value) {
+			return nil, fmt.Errorf("bad address (%s)", value)
+		}
+		result = append(result, common.HexToAddress(value))
+	}
+	return result, nil
+}
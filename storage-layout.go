@@ -0,0 +1,304 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+type storageLayoutEntry struct {
+	Contract string `json:"contract"`
+	Label    string `json:"label"`
+	Offset   uint64 `json:"offset"`
+	Slot     string `json:"slot"`
+	Type     string `json:"type"`
+}
+
+type storageLayoutType struct {
+	Encoding      string               `json:"encoding"`
+	Label         string               `json:"label"`
+	NumberOfBytes string               `json:"numberOfBytes"`
+	Base          string               `json:"base,omitempty"`
+	Key           string               `json:"key,omitempty"`
+	Value         string               `json:"value,omitempty"`
+	Members       []storageLayoutEntry `json:"members,omitempty"`
+}
+
+// storageLayout is a solc storage layout (forge must be configured with extra_output = ["storageLayout"])
+type storageLayout struct {
+	Storage []storageLayoutEntry         `json:"storage"`
+	Types   map[string]storageLayoutType `json:"types"`
+}
+
+const (
+	injectorV1ContextHolder = "InjectorContextHolder"
+	injectorV2ContextHolder = "InjectorContextHolderV2"
+)
+
+type storageLayoutIssue struct {
+	Contract string
+	Fatal    bool
+	Message  string
+}
+
+func (e storageLayoutEntry) slot() uint64 {
+	slot, _ := strconv.ParseUint(e.Slot, 10, 64)
+	return slot
+}
+
+func (t storageLayoutType) size() uint64 {
+	size, _ := strconv.ParseUint(t.NumberOfBytes, 10, 64)
+	return size
+}
+
+// storageVariable identifies variable of the layout, base contracts may declare variables with the same name
+// (e.g. every OZ upgradeable base has its own __gap)
+type storageVariable struct {
+	contract string
+	label    string
+}
+
+func (e storageLayoutEntry) variable() storageVariable {
+	return storageVariable{contract: e.Contract, label: e.Label}
+}
+
+// contractName returns name of the contract declaring the variable w/o source path
+func (e storageLayoutEntry) contractName() string {
+	return e.Contract[strings.LastIndex(e.Contract, ":")+1:]
+}
+
+// isStorageGap returns true for reserved slots like "uint256[100 - 9] private __reserved"
+func (e storageLayoutEntry) isStorageGap() bool {
+	return e.Label == "__reserved" || e.Label == "__gap"
+}
+
+// injectorVersion detects what injector is used by the contract, injector's variables are declared first
+func (l *storageLayout) injectorVersion() string {
+	for _, e := range l.Storage {
+		contractName := e.contractName()
+		if contractName == injectorV1ContextHolder || contractName == injectorV2ContextHolder {
+			return contractName
+		}
+	}
+	return ""
+}
+
+// typeName returns type description w/o AST identifiers (they differ between compilations)
+func (l *storageLayout) typeName(typeId string) string {
+	t, ok := l.Types[typeId]
+	if !ok {
+		return typeId
+	}
+	switch {
+	case t.Encoding == "mapping":
+		return fmt.Sprintf("mapping(%s => %s)", l.typeName(t.Key), l.typeName(t.Value))
+	case t.Base != "":
+		return fmt.Sprintf("%s (%s bytes)", t.Label, t.NumberOfBytes)
+	}
+	return t.Label
+}
+
+// compatibleTypes checks that new type can read data written by the old type, growable is true when
+// the value isn't stored in place (mapping values and dynamic array items), so it might be extended
+func compatibleTypes(oldLayout, newLayout *storageLayout, oldTypeId, newTypeId string, growable bool) (bool, string) {
+	oldType, newType := oldLayout.Types[oldTypeId], newLayout.Types[newTypeId]
+	if oldType.Encoding != newType.Encoding {
+		return false, fmt.Sprintf("encoding changed from %s to %s", oldType.Encoding, newType.Encoding)
+	}
+	if oldType.Encoding == "mapping" {
+		if oldLayout.typeName(oldType.Key) != newLayout.typeName(newType.Key) {
+			return false, fmt.Sprintf("mapping key changed from %s to %s", oldLayout.typeName(oldType.Key), newLayout.typeName(newType.Key))
+		}
+		return compatibleTypes(oldLayout, newLayout, oldType.Value, newType.Value, true)
+	}
+	if oldType.Base != "" || newType.Base != "" {
+		if ok, reason := compatibleTypes(oldLayout, newLayout, oldType.Base, newType.Base, oldType.Encoding == "dynamic_array"); !ok {
+			return false, reason
+		}
+		if oldType.Encoding != "dynamic_array" && oldType.size() != newType.size() {
+			return false, fmt.Sprintf("array size changed from %s to %s bytes", oldType.NumberOfBytes, newType.NumberOfBytes)
+		}
+		return true, ""
+	}
+	if len(oldType.Members) > 0 || len(newType.Members) > 0 {
+		if len(newType.Members) < len(oldType.Members) {
+			return false, fmt.Sprintf("struct %s lost %d member(s)", oldType.Label, len(oldType.Members)-len(newType.Members))
+		}
+		for i, oldMember := range oldType.Members {
+			newMember := newType.Members[i]
+			if oldMember.slot() != newMember.slot() || oldMember.Offset != newMember.Offset {
+				return false, fmt.Sprintf("struct member %s.%s moved", oldType.Label, oldMember.Label)
+			}
+			if ok, reason := compatibleTypes(oldLayout, newLayout, oldMember.Type, newMember.Type, false); !ok {
+				return false, fmt.Sprintf("struct member %s.%s: %s", oldType.Label, oldMember.Label, reason)
+			}
+		}
+		if !growable && oldType.size() != newType.size() {
+			return false, fmt.Sprintf("struct %s size changed from %s to %s bytes", oldType.Label, oldType.NumberOfBytes, newType.NumberOfBytes)
+		}
+		return true, ""
+	}
+	if oldType.Label != newType.Label || oldType.size() != newType.size() {
+		return false, fmt.Sprintf("type changed from %s to %s", oldType.Label, newType.Label)
+	}
+	return true, ""
+}
+
+// compareStorageLayouts verifies that every variable of the old layout stays in the same slot with compatible type
+func compareStorageLayouts(contract string, oldLayout, newLayout *storageLayout) []storageLayoutIssue {
+	var issues []storageLayoutIssue
+	addIssue := func(fatal bool, format string, args ...interface{}) {
+		issues = append(issues, storageLayoutIssue{Contract: contract, Fatal: fatal, Message: fmt.Sprintf(format, args...)})
+	}
+	if oldVersion, newVersion := oldLayout.injectorVersion(), newLayout.injectorVersion(); oldVersion != newVersion {
+		addIssue(true, "injector is switched from %s to %s, storage layouts are not compatible", oldVersion, newVersion)
+	}
+	type position struct {
+		slot   uint64
+		offset uint64
+	}
+	newByPosition := make(map[position]storageLayoutEntry)
+	newByVariable := make(map[storageVariable]storageLayoutEntry)
+	for _, e := range newLayout.Storage {
+		newByPosition[position{e.slot(), e.Offset}] = e
+		newByVariable[e.variable()] = e
+	}
+	for _, oldEntry := range oldLayout.Storage {
+		newEntry, ok := newByPosition[position{oldEntry.slot(), oldEntry.Offset}]
+		if oldEntry.isStorageGap() {
+			oldEnd := oldEntry.slot() + oldLayout.Types[oldEntry.Type].size()/32
+			if !ok || !newEntry.isStorageGap() {
+				// gap might be consumed by new variables, find the gap of the same contract
+				newEntry, ok = newByVariable[oldEntry.variable()]
+			}
+			if !ok {
+				addIssue(true, "storage gap %s of %s (slot %d) is removed", oldEntry.Label, oldEntry.contractName(), oldEntry.slot())
+				continue
+			}
+			newEnd := newEntry.slot() + newLayout.Types[newEntry.Type].size()/32
+			if oldEnd != newEnd {
+				addIssue(true, "storage gap %s of %s ends at slot %d instead of %d, following variables are shifted", oldEntry.Label, oldEntry.contractName(), newEnd, oldEnd)
+			} else if oldSize, newSize := oldLayout.Types[oldEntry.Type].size(), newLayout.Types[newEntry.Type].size(); newSize < oldSize {
+				addIssue(false, "storage gap %s of %s is shrunk by %d slot(s)", oldEntry.Label, oldEntry.contractName(), (oldSize-newSize)/32)
+			}
+			continue
+		}
+		if !ok {
+			if moved, found := newByVariable[oldEntry.variable()]; found {
+				addIssue(true, "variable %s is moved from slot %d:%d to %d:%d", oldEntry.Label, oldEntry.slot(), oldEntry.Offset, moved.slot(), moved.Offset)
+			} else {
+				addIssue(true, "variable %s (slot %d:%d) is removed", oldEntry.Label, oldEntry.slot(), oldEntry.Offset)
+			}
+			continue
+		}
+		if newEntry.variable() != oldEntry.variable() {
+			if _, found := newByVariable[oldEntry.variable()]; found {
+				addIssue(true, "variables are reordered, slot %d:%d is %s instead of %s", oldEntry.slot(), oldEntry.Offset, newEntry.Label, oldEntry.Label)
+				continue
+			}
+			addIssue(false, "variable %s (slot %d:%d) is renamed to %s", oldEntry.Label, oldEntry.slot(), oldEntry.Offset, newEntry.Label)
+		}
+		if ok, reason := compatibleTypes(oldLayout, newLayout, oldEntry.Type, newEntry.Type, false); !ok {
+			addIssue(true, "variable %s is retyped: %s", oldEntry.Label, reason)
+		}
+	}
+	return issues
+}
+
+// findKnownArtifact looks for the artifact with exactly the same deployed bytecode, embedded artifacts are checked first
+func findKnownArtifact(contract systemContract, code []byte, knownDirs []string) ([]byte, string, error) {
+	for _, dir := range append([]string{""}, knownDirs...) {
+		rawArtifact, err := readSystemContractArtifact(dir, contract)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, "", err
+		}
+		artifact, err := parseArtifact(rawArtifact)
+		if err != nil {
+			return nil, "", err
+		}
+		if bytes.Equal(hexutil.MustDecode(artifact.DeployedBytecode), code) {
+			if dir == "" {
+				dir = "embedded"
+			}
+			return rawArtifact, dir, nil
+		}
+	}
+	return nil, "", fmt.Errorf("deployed bytecode of %s doesn't match any known artifact", contract.Name)
+}
+
+// checkStorageLayoutCompatibility compares storage layout of the deployed contract with the new artifact
+func checkStorageLayoutCompatibility(contract systemContract, deployedCode []byte, newRawArtifact []byte, knownDirs []string) ([]storageLayoutIssue, error) {
+	oldRawArtifact, _, err := findKnownArtifact(contract, deployedCode, knownDirs)
+	if err != nil {
+		return nil, err
+	}
+	oldArtifact, err := parseArtifact(oldRawArtifact)
+	if err != nil {
+		return nil, err
+	}
+	newArtifact, err := parseArtifact(newRawArtifact)
+	if err != nil {
+		return nil, err
+	}
+	if oldArtifact.StorageLayout == nil || newArtifact.StorageLayout == nil {
+		return nil, fmt.Errorf("artifact of %s has no storage layout, add storageLayout to forge's extra_output", contract.Name)
+	}
+	return compareStorageLayouts(contract.Name, oldArtifact.StorageLayout, newArtifact.StorageLayout), nil
+}
+
+func runStorageLayoutCommand(args []string) error {
+	flags := flag.NewFlagSet("storage-layout", flag.ContinueOnError)
+	stateFile := flags.String("state", "", "genesis file or state dump of the target network")
+	artifactsDir := flags.String("artifacts", "", "forge output directory with new artifacts (embedded artifacts are used by default)")
+	var knownDirs stringListFlag
+	flags.Var(&knownDirs, "known", "forge output directory of the previous release (can be repeated)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *stateFile == "" {
+		return fmt.Errorf("state file is required")
+	}
+	source, err := readStateSource(*stateFile)
+	if err != nil {
+		return err
+	}
+	fatalIssues := 0
+	for _, contract := range systemContracts {
+		account, ok := source.Alloc[contract.Address]
+		if !ok || len(account.Code) == 0 {
+			fmt.Printf(" ~ %s: not deployed, skipping\n", contract.Name)
+			continue
+		}
+		newRawArtifact, err := readSystemContractArtifact(*artifactsDir, contract)
+		if err != nil {
+			return err
+		}
+		issues, err := checkStorageLayoutCompatibility(contract, account.Code, newRawArtifact, knownDirs)
+		if err != nil {
+			return err
+		}
+		if len(issues) == 0 {
+			fmt.Printf(" + %s: compatible\n", contract.Name)
+			continue
+		}
+		for _, issue := range issues {
+			if issue.Fatal {
+				fatalIssues++
+				fmt.Printf(" - %s: %s\n", issue.Contract, issue.Message)
+			} else {
+				fmt.Printf(" ~ %s: %s\n", issue.Contract, issue.Message)
+			}
+		}
+	}
+	if fatalIssues > 0 {
+		return fmt.Errorf("found %d storage layout incompatibilities", fatalIssues)
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+var testStorageTypes = map[string]storageLayoutType{
+	"t_uint256":                      {Encoding: "inplace", Label: "uint256", NumberOfBytes: "32"},
+	"t_address":                      {Encoding: "inplace", Label: "address", NumberOfBytes: "20"},
+	"t_array(t_uint256)49_storage":   {Encoding: "inplace", Label: "uint256[49]", NumberOfBytes: "1568", Base: "t_uint256"},
+	"t_array(t_uint256)50_storage":   {Encoding: "inplace", Label: "uint256[50]", NumberOfBytes: "1600", Base: "t_uint256"},
+	"t_mapping(t_address,t_uint256)": {Encoding: "mapping", Label: "mapping(address => uint256)", NumberOfBytes: "32", Key: "t_address", Value: "t_uint256"},
+}
+
+// testStorageLayout creates layout from "contract:label:slot:type" entries
+func testStorageLayout(entries ...string) *storageLayout {
+	layout := &storageLayout{Types: testStorageTypes}
+	for _, e := range entries {
+		parts := strings.Split(e, ":")
+		slot, _ := strconv.ParseUint(parts[2], 10, 64)
+		layout.Storage = append(layout.Storage, storageLayoutEntry{
+			Contract: "contracts/" + parts[0] + ".sol:" + parts[0],
+			Label:    parts[1],
+			Slot:     strconv.FormatUint(slot, 10),
+			Type:     parts[3],
+		})
+	}
+	return layout
+}
+
+func TestCompareStorageLayouts(t *testing.T) {
+	for _, test := range []struct {
+		name      string
+		oldLayout *storageLayout
+		newLayout *storageLayout
+		issues    []string
+		fatal     int
+	}{
+		{
+			name:      "same layout",
+			oldLayout: testStorageLayout("A:x:0:t_uint256", "A:y:1:t_address"),
+			newLayout: testStorageLayout("A:x:0:t_uint256", "A:y:1:t_address"),
+		},
+		{
+			name:      "new variable is appended",
+			oldLayout: testStorageLayout("A:x:0:t_uint256"),
+			newLayout: testStorageLayout("A:x:0:t_uint256", "A:y:1:t_address"),
+		},
+		{
+			name:      "variable is removed",
+			oldLayout: testStorageLayout("A:x:0:t_uint256", "A:y:1:t_address"),
+			newLayout: testStorageLayout("A:x:0:t_uint256"),
+			issues:    []string{"variable y (slot 1:0) is removed"},
+			fatal:     1,
+		},
+		{
+			name:      "variable is retyped",
+			oldLayout: testStorageLayout("A:x:0:t_uint256"),
+			newLayout: testStorageLayout("A:x:0:t_address"),
+			issues:    []string{"variable x is retyped: type changed from uint256 to address"},
+			fatal:     1,
+		},
+		{
+			name:      "variable is renamed",
+			oldLayout: testStorageLayout("A:x:0:t_uint256"),
+			newLayout: testStorageLayout("A:z:0:t_uint256"),
+			issues:    []string{"variable x (slot 0:0) is renamed to z"},
+		},
+		{
+			name:      "variables are reordered",
+			oldLayout: testStorageLayout("A:x:0:t_uint256", "A:y:1:t_uint256"),
+			newLayout: testStorageLayout("A:y:0:t_uint256", "A:x:1:t_uint256"),
+			issues:    []string{"variables are reordered, slot 0:0 is y instead of x", "variables are reordered, slot 1:0 is x instead of y"},
+			fatal:     2,
+		},
+		{
+			name:      "variable of another base with the same name is not a move",
+			oldLayout: testStorageLayout("A:owner:0:t_address", "B:owner:1:t_address"),
+			newLayout: testStorageLayout("A:owner:0:t_address"),
+			issues:    []string{"variable owner (slot 1:0) is removed"},
+			fatal:     1,
+		},
+		{
+			name: "gaps of two bases, first gap is consumed",
+			oldLayout: testStorageLayout(
+				"A:x:0:t_uint256", "A:__gap:1:t_array(t_uint256)50_storage",
+				"B:y:51:t_uint256", "B:__gap:52:t_array(t_uint256)50_storage",
+			),
+			newLayout: testStorageLayout(
+				"A:x:0:t_uint256", "A:z:1:t_uint256", "A:__gap:2:t_array(t_uint256)49_storage",
+				"B:y:51:t_uint256", "B:__gap:52:t_array(t_uint256)50_storage",
+			),
+			issues: []string{"storage gap __gap of A is shrunk by 1 slot(s)"},
+		},
+		{
+			name: "gaps of two bases, second gap is consumed",
+			oldLayout: testStorageLayout(
+				"A:x:0:t_uint256", "A:__gap:1:t_array(t_uint256)50_storage",
+				"B:y:51:t_uint256", "B:__gap:52:t_array(t_uint256)50_storage",
+			),
+			newLayout: testStorageLayout(
+				"A:x:0:t_uint256", "A:__gap:1:t_array(t_uint256)50_storage",
+				"B:y:51:t_uint256", "B:z:52:t_mapping(t_address,t_uint256)", "B:__gap:53:t_array(t_uint256)49_storage",
+			),
+			issues: []string{"storage gap __gap of B is shrunk by 1 slot(s)"},
+		},
+		{
+			name: "gap is not shrunk for new variable",
+			oldLayout: testStorageLayout(
+				"A:x:0:t_uint256", "A:__gap:1:t_array(t_uint256)50_storage",
+				"B:y:51:t_uint256", "B:__gap:52:t_array(t_uint256)50_storage",
+			),
+			newLayout: testStorageLayout(
+				"A:x:0:t_uint256", "A:z:1:t_uint256", "A:__gap:2:t_array(t_uint256)50_storage",
+				"B:y:52:t_uint256", "B:__gap:53:t_array(t_uint256)50_storage",
+			),
+			issues: []string{
+				"storage gap __gap of A ends at slot 52 instead of 51, following variables are shifted",
+				"variable y is moved from slot 51:0 to 52:0",
+				"storage gap __gap of B ends at slot 103 instead of 102, following variables are shifted",
+			},
+			fatal: 3,
+		},
+		{
+			name:      "gap is removed",
+			oldLayout: testStorageLayout("A:x:0:t_uint256", "A:__gap:1:t_array(t_uint256)50_storage"),
+			newLayout: testStorageLayout("A:x:0:t_uint256"),
+			issues:    []string{"storage gap __gap of A (slot 1) is removed"},
+			fatal:     1,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			issues := compareStorageLayouts("Test", test.oldLayout, test.newLayout)
+			var messages []string
+			fatal := 0
+			for _, issue := range issues {
+				messages = append(messages, issue.Message)
+				if issue.Fatal {
+					fatal++
+				}
+			}
+			if strings.Join(messages, "\n") != strings.Join(test.issues, "\n") {
+				t.Fatalf("expected issues:\n%s\ngot:\n%s", strings.Join(test.issues, "\n"), strings.Join(messages, "\n"))
+			}
+			if fatal != test.fatal {
+				t.Errorf("expected %d fatal issues, got %d", test.fatal, fatal)
+			}
+		})
+	}
+}
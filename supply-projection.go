@@ -0,0 +1,370 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+
+	"github.com/holiman/uint256"
+)
+
+const secondsPerYear = 365 * 24 * 60 * 60
+
+// inflationScheduleEntry sets annual inflation (in basis points, 800 is 8%) starting from the epoch
+type inflationScheduleEntry struct {
+	FromEpoch    uint64 `json:"fromEpoch"`
+	InflationPct uint64 `json:"inflationPct"`
+}
+
+type inflationSchedule []inflationScheduleEntry
+
+func (s inflationSchedule) at(epoch uint64) uint64 {
+	var result uint64
+	for _, e := range s {
+		if e.FromEpoch <= epoch {
+			result = e.InflationPct
+		}
+	}
+	return result
+}
+
+type supplyProjectionEpoch struct {
+	Epoch          uint64                      `json:"epoch"`
+	Block          uint64                      `json:"block"`
+	InflationPct   uint64                      `json:"inflationPct"`
+	Minted         *big.Int                    `json:"minted"`
+	TotalSupply    *big.Int                    `json:"totalSupply"`
+	ToStaking      *big.Int                    `json:"toStaking"`
+	ToSystemReward *big.Int                    `json:"toSystemReward"`
+	Treasury       map[common.Address]*big.Int `json:"treasury"`
+}
+
+// genesisTotalSupply sums balances of all genesis accounts (faucet, staked amounts and system contracts)
+func genesisTotalSupply(alloc core.GenesisAlloc) *big.Int {
+	result := big.NewInt(0)
+	for _, account := range alloc {
+		if account.Balance != nil {
+			result.Add(result, account.Balance)
+		}
+	}
+	return result
+}
+
+func systemTreasuryAccounts(env *simulationEnv) ([]common.Address, error) {
+	result, err := env.staticCall(systemRewardAddress, mustParseArtifactABI(systemRewardRawArtifact), "getDistributionShares")
+	if err != nil {
+		return nil, err
+	}
+	var shares []struct {
+		Account common.Address
+		Share   uint16
+	}
+	if err := abi.ConvertType(result[0], &shares); err != nil {
+		return nil, err
+	}
+	var accounts []common.Address
+	for _, s := range shares {
+		accounts = append(accounts, s.Account)
+	}
+	return accounts, nil
+}
+
+// blockProduction maps validators to their weights in block production, e.g. number of blocks produced per epoch
+type blockProduction map[common.Address]uint64
+
+// roundRobinProduction gives every active validator the same weight
+func roundRobinProduction(validators []common.Address) blockProduction {
+	result := make(blockProduction, len(validators))
+	for _, v := range validators {
+		result[v] = 1
+	}
+	return result
+}
+
+// readBlockProductionCSV reads file with validator and blocks columns, blocks are relative weights of validators
+func readBlockProductionCSV(filePath string) (blockProduction, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	reader := csv.NewReader(f)
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse block production file (%s): %w", filePath, err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("block production file (%s) is empty", filePath)
+	}
+	columns := make(map[string]int)
+	for i, name := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{"validator", "blocks"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("block production file (%s) has no %s column", filePath, name)
+		}
+	}
+	result := make(blockProduction)
+	for i, record := range records[1:] {
+		source := fmt.Sprintf("%s:%d", filePath, i+2)
+		validator, err := parseChecksumAddress(record[columns["validator"]])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", source, err)
+		}
+		var blocks uint64
+		if _, err := fmt.Sscanf(strings.TrimSpace(record[columns["blocks"]]), "%d", &blocks); err != nil {
+			return nil, fmt.Errorf("%s: bad number of blocks (%s)", source, record[columns["blocks"]])
+		}
+		if _, ok := result[validator]; ok {
+			return nil, fmt.Errorf("%s: duplicate validator (%s)", source, validator.Hex())
+		}
+		result[validator] = blocks
+	}
+	return result, nil
+}
+
+// split divides minted amount between validators proportionally to their weights,
+// the remainder goes to the first validator with non-zero weight
+func (p blockProduction) split(validators []common.Address, minted *big.Int) ([]*big.Int, error) {
+	active := make(map[common.Address]bool, len(validators))
+	for _, v := range validators {
+		active[v] = true
+	}
+	total := new(big.Int)
+	for v, weight := range p {
+		if !active[v] {
+			return nil, fmt.Errorf("block producer (%s) is not an active validator", v.Hex())
+		}
+		total.Add(total, new(big.Int).SetUint64(weight))
+	}
+	if total.Sign() == 0 {
+		return nil, fmt.Errorf("total weight of block producers is zero")
+	}
+	amounts := make([]*big.Int, len(validators))
+	distributed, first := new(big.Int), -1
+	for i, v := range validators {
+		amounts[i] = new(big.Int).Mul(minted, new(big.Int).SetUint64(p[v]))
+		amounts[i].Div(amounts[i], total)
+		distributed.Add(distributed, amounts[i])
+		if first < 0 && p[v] > 0 {
+			first = i
+		}
+	}
+	amounts[first].Add(amounts[first], new(big.Int).Sub(minted, distributed))
+	return amounts, nil
+}
+
+// projectSupply drives Tokenomics deposits epoch by epoch, minted amount of the epoch is split between active validators
+// by the block production model (round-robin if production is nil) and deposited once per validator at the beginning
+// of the epoch, system fee is claimed at the end of every epoch so treasury payouts are not delayed by auto claim threshold
+func projectSupply(env *simulationEnv, totalSupply *big.Int, schedule inflationSchedule, epochs uint64, production blockProduction) ([]supplyProjectionEpoch, error) {
+	if len(env.StateDB.GetCode(tokenomicsAddress)) == 0 {
+		return nil, fmt.Errorf("tokenomics contract (%s) is not deployed in the genesis", tokenomicsAddress.Hex())
+	}
+	tokenomicsABI := mustParseArtifactABI(tokenomicsRawArtifact)
+	systemRewardABI := mustParseArtifactABI(systemRewardRawArtifact)
+	result, err := env.staticCall(stakingAddress, mustParseArtifactABI(stakingRawArtifact), "getValidators")
+	if err != nil {
+		return nil, err
+	}
+	validators := result[0].([]common.Address)
+	if len(validators) == 0 {
+		return nil, fmt.Errorf("there are no active validators")
+	}
+	if production == nil {
+		production = roundRobinProduction(validators)
+	}
+	treasury, err := systemTreasuryAccounts(env)
+	if err != nil {
+		return nil, err
+	}
+	epochLength := env.Config.Parlia.Epoch
+	epochSeconds := epochLength * simulationBlockPeriod(env.Config)
+	balanceOf := func(address common.Address) *big.Int {
+		return env.StateDB.GetBalance(address).ToBig()
+	}
+	var projection []supplyProjectionEpoch
+	supply := new(big.Int).Set(totalSupply)
+	for epoch := uint64(1); epoch <= epochs; epoch++ {
+		inflationPct := schedule.at(epoch)
+		minted := new(big.Int).Mul(supply, new(big.Int).SetUint64(inflationPct*epochSeconds))
+		minted.Div(minted, big.NewInt(10000*secondsPerYear))
+		supply = new(big.Int).Add(supply, minted)
+		row := supplyProjectionEpoch{
+			Epoch:        epoch,
+			Block:        env.Header.Number.Uint64(),
+			InflationPct: inflationPct,
+			Minted:       minted,
+			TotalSupply:  supply,
+			Treasury:     make(map[common.Address]*big.Int),
+		}
+		stakingBefore, systemRewardBefore := balanceOf(stakingAddress), balanceOf(systemRewardAddress)
+		treasuryBefore := make(map[common.Address]*big.Int)
+		for _, account := range treasury {
+			treasuryBefore[account] = balanceOf(account)
+		}
+		amounts, err := production.split(validators, minted)
+		if err != nil {
+			return nil, err
+		}
+		for i, validator := range validators {
+			amount := amounts[i]
+			if amount.Sign() == 0 {
+				continue
+			}
+			input, err := tokenomicsABI.Pack("deposit", validator, supply, new(big.Int).SetUint64(inflationPct))
+			if err != nil {
+				return nil, err
+			}
+			// new supply is minted to the block producer and deposited by the coinbase
+			env.Header.Coinbase = validator
+			env.StateDB.AddBalance(validator, uint256.MustFromBig(amount))
+			if r := env.call(validator, tokenomicsAddress, input, amount, simulationGasLimit); r.Err != nil {
+				return nil, fmt.Errorf("deposit failed at epoch %d for %s: %s", epoch, validator.Hex(), describeCallError(r.Return, r.Err))
+			}
+		}
+		input, _ := systemRewardABI.Pack("claimSystemFee")
+		if r := env.call(common.Address{}, systemRewardAddress, input, nil, simulationGasLimit); r.Err != nil {
+			return nil, fmt.Errorf("system fee claim failed at epoch %d: %s", epoch, describeCallError(r.Return, r.Err))
+		}
+		row.ToStaking = new(big.Int).Sub(balanceOf(stakingAddress), stakingBefore)
+		row.ToSystemReward = new(big.Int).Sub(balanceOf(systemRewardAddress), systemRewardBefore)
+		for _, account := range treasury {
+			payout := new(big.Int).Sub(balanceOf(account), treasuryBefore[account])
+			row.Treasury[account] = payout
+			// claimed fee left system reward contract, but it's still an amount sent to the system reward
+			row.ToSystemReward.Add(row.ToSystemReward, payout)
+		}
+		projection = append(projection, row)
+		env.advanceBlocks(epochLength)
+	}
+	return projection, nil
+}
+
+func writeSupplyProjectionCSV(targetFile string, projection []supplyProjectionEpoch) error {
+	var treasury []common.Address
+	if len(projection) > 0 {
+		treasury = sortedAddresses(projection[0].Treasury)
+	}
+	sb := &strings.Builder{}
+	w := csv.NewWriter(sb)
+	header := []string{"epoch", "block", "inflationPct", "minted", "totalSupply", "toStaking", "toSystemReward"}
+	for _, account := range treasury {
+		header = append(header, "treasury:"+account.Hex())
+	}
+	w.Write(header)
+	for _, row := range projection {
+		record := []string{
+			fmt.Sprintf("%d", row.Epoch),
+			fmt.Sprintf("%d", row.Block),
+			fmt.Sprintf("%d", row.InflationPct),
+			row.Minted.String(),
+			row.TotalSupply.String(),
+			row.ToStaking.String(),
+			row.ToSystemReward.String(),
+		}
+		for _, account := range treasury {
+			record = append(record, row.Treasury[account].String())
+		}
+		w.Write(record)
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+	return writeOutputFile(targetFile, []byte(sb.String()))
+}
+
+// sortedAddresses returns map keys in a stable order
+func sortedAddresses[T any](m map[common.Address]T) []common.Address {
+	var result []common.Address
+	for address := range m {
+		result = append(result, address)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Hex() < result[j].Hex()
+	})
+	return result
+}
+
+func runProjectSupplyCommand(args []string) error {
+	flags := flag.NewFlagSet("project-supply", flag.ContinueOnError)
+	genesisFile := flags.String("genesis", "", "genesis file of the network")
+	inflationPct := flags.Uint64("inflation", 0, "annual inflation in basis points (800 is 8%)")
+	scheduleFile := flags.String("schedule", "", "inflation schedule file, JSON list of {fromEpoch, inflationPct} (overrides -inflation)")
+	epochs := flags.Uint64("epochs", 365, "number of epochs to project")
+	blocksFile := flags.String("blocks", "", "CSV file with validator and blocks columns (blocks produced per epoch or weights), round-robin by default")
+	format := flags.String("format", "csv", "output format: csv or json")
+	outputFile := flags.String("output", "stdout", "output file")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *genesisFile == "" {
+		return fmt.Errorf("genesis file is required")
+	}
+	inflationSet := false
+	flags.Visit(func(f *flag.Flag) {
+		inflationSet = inflationSet || f.Name == "inflation"
+	})
+	if !inflationSet && *scheduleFile == "" {
+		return fmt.Errorf("inflation is required, use -inflation or -schedule")
+	}
+	schedule := inflationSchedule{{FromEpoch: 0, InflationPct: *inflationPct}}
+	if *scheduleFile != "" {
+		fileContents, err := os.ReadFile(*scheduleFile)
+		if err != nil {
+			return err
+		}
+		schedule = nil
+		if err := json.Unmarshal(fileContents, &schedule); err != nil {
+			return fmt.Errorf("failed to parse inflation schedule (%s): %w", *scheduleFile, err)
+		}
+		sort.Slice(schedule, func(i, j int) bool { return schedule[i].FromEpoch < schedule[j].FromEpoch })
+	}
+	source, err := readStateSource(*genesisFile)
+	if err != nil {
+		return err
+	}
+	if source.Genesis == nil {
+		return fmt.Errorf("file (%s) is not a genesis file", *genesisFile)
+	}
+	if source.Genesis.Config.Parlia == nil || source.Genesis.Config.Parlia.Epoch == 0 {
+		return fmt.Errorf("genesis has no parlia epoch length")
+	}
+	env, err := newSimulationEnv(source.Genesis.Config, source.Alloc, 1, source.Genesis.Timestamp+simulationBlockPeriod(source.Genesis.Config))
+	if err != nil {
+		return err
+	}
+	if err := env.initSystemContracts(); err != nil {
+		return err
+	}
+	var production blockProduction
+	if *blocksFile != "" {
+		if production, err = readBlockProductionCSV(*blocksFile); err != nil {
+			return err
+		}
+	}
+	projection, err := projectSupply(env, genesisTotalSupply(source.Alloc), schedule, *epochs, production)
+	if err != nil {
+		return err
+	}
+	switch *format {
+	case "csv":
+		return writeSupplyProjectionCSV(*outputFile, projection)
+	case "json":
+		result, _ := json.MarshalIndent(projection, "", "  ")
+		return writeOutputFile(*outputFile, append(result, '\n'))
+	}
+	return fmt.Errorf("unknown output format (%s)", *format)
+}
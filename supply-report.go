@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+)
+
+// supply categories of the genesis alloc accounts
+const (
+	supplyCategoryFaucet         = "faucet"
+	supplyCategoryStaking        = "staking"
+	supplyCategorySystemContract = "system contract"
+	supplyCategoryAllocation     = "allocation"
+	supplyCategoryOther          = "other"
+)
+
+// supplyConstraints are enforced by the build, amounts are in CHZ (e.g. "8,888,888,888")
+type supplyConstraints struct {
+	// exact total supply of the genesis
+	Total string `json:"total,omitempty"`
+	// maximum total supply of the genesis
+	Cap string `json:"cap,omitempty"`
+	// exact totals by label (faucet and allocation labels or system contract names)
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+type supplyReportEntry struct {
+	Category string   `json:"category"`
+	Label    string   `json:"label"`
+	Accounts int      `json:"accounts"`
+	Total    *big.Int `json:"total"`
+}
+
+type supplyReport struct {
+	Entries []supplyReportEntry `json:"entries"`
+	Total   *big.Int            `json:"total"`
+}
+
+// labelTotal sums entries of the label over all categories
+func (r *supplyReport) labelTotal(label string) (*big.Int, bool) {
+	result, found := big.NewInt(0), false
+	for _, e := range r.Entries {
+		if e.Label == label {
+			result.Add(result, e.Total)
+			found = true
+		}
+	}
+	return result, found
+}
+
+// buildSupplyReport breaks genesis balances down into categories and labels, every account is counted once
+func buildSupplyReport(config genesisConfig, alloc core.GenesisAlloc, allocations []allocationEntry) *supplyReport {
+	allocationLabels := make(map[common.Address]string)
+	for _, entry := range allocations {
+		allocationLabels[entry.Address] = entry.Label
+	}
+	entries := make(map[[2]string]*supplyReportEntry)
+	for address, account := range alloc {
+		if account.Balance == nil || account.Balance.Sign() == 0 {
+			continue
+		}
+		var category, label string
+		if c, ok := systemContractByAddress(address); ok {
+			category, label = supplyCategorySystemContract, c.Name
+			if address == stakingAddress {
+				category = supplyCategoryStaking
+			}
+		} else if _, ok := config.Faucet[address]; ok {
+			category, label = supplyCategoryFaucet, config.FaucetLabels[address]
+		} else if l, ok := allocationLabels[address]; ok {
+			category, label = supplyCategoryAllocation, l
+		} else {
+			category = supplyCategoryOther
+		}
+		if label == "" {
+			label = "unlabeled"
+		}
+		key := [2]string{category, label}
+		entry, ok := entries[key]
+		if !ok {
+			entry = &supplyReportEntry{Category: category, Label: label, Total: big.NewInt(0)}
+			entries[key] = entry
+		}
+		entry.Accounts++
+		entry.Total.Add(entry.Total, account.Balance)
+	}
+	report := &supplyReport{Total: genesisTotalSupply(alloc)}
+	for _, entry := range entries {
+		report.Entries = append(report.Entries, *entry)
+	}
+	sort.Slice(report.Entries, func(i, j int) bool {
+		if report.Entries[i].Category != report.Entries[j].Category {
+			return report.Entries[i].Category < report.Entries[j].Category
+		}
+		return report.Entries[i].Label < report.Entries[j].Label
+	})
+	return report
+}
+
+// checkSupplyConstraints verifies declared total, cap and label totals, all violations are reported at once
+func checkSupplyConstraints(report *supplyReport, constraints *supplyConstraints) error {
+	if constraints == nil {
+		return nil
+	}
+	var issues []string
+	if constraints.Total != "" {
+		expected, err := parseAllocationAmount(constraints.Total)
+		if err != nil {
+			return fmt.Errorf("bad expected total supply: %w", err)
+		}
+		if report.Total.Cmp(expected) != 0 {
+			issues = append(issues, fmt.Sprintf("total supply is %s, expected %s", formatAmount(report.Total), formatAmount(expected)))
+		}
+	}
+	if constraints.Cap != "" {
+		limit, err := parseAllocationAmount(constraints.Cap)
+		if err != nil {
+			return fmt.Errorf("bad supply cap: %w", err)
+		}
+		if report.Total.Cmp(limit) > 0 {
+			issues = append(issues, fmt.Sprintf("total supply %s exceeds cap %s", formatAmount(report.Total), formatAmount(limit)))
+		}
+	}
+	var labels []string
+	for label := range constraints.Labels {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	for _, label := range labels {
+		expected, err := parseAllocationAmount(constraints.Labels[label])
+		if err != nil {
+			return fmt.Errorf("bad expected supply of %s: %w", label, err)
+		}
+		total, ok := report.labelTotal(label)
+		if !ok {
+			issues = append(issues, fmt.Sprintf("there are no accounts labeled %s, expected %s", label, formatAmount(expected)))
+		} else if total.Cmp(expected) != 0 {
+			issues = append(issues, fmt.Sprintf("%s holds %s, expected %s", label, formatAmount(total), formatAmount(expected)))
+		}
+	}
+	if len(issues) > 0 {
+		return fmt.Errorf("supply check failed:\n - %s", strings.Join(issues, "\n - "))
+	}
+	return nil
+}
+
+func printSupplyReport(w io.Writer, report *supplyReport) {
+	fmt.Fprintf(w, "%-16s %-32s %10s %36s\n", "category", "label", "accounts", "total")
+	for _, e := range report.Entries {
+		fmt.Fprintf(w, "%-16s %-32s %10d %36s\n", e.Category, e.Label, e.Accounts, formatAmount(e.Total))
+	}
+	fmt.Fprintf(w, "%-16s %-32s %10s %36s\n", "total", "", "", formatAmount(report.Total))
+}
@@ -0,0 +1,19 @@
+// Code generated by create-genesis bindings - DO NOT EDIT.
+
+package systemcontracts
+
+import "github.com/ethereum/go-ethereum/common"
+
+// system contract addresses
+var (
+	StakingAddress            = common.HexToAddress("0x0000000000000000000000000000000000001000")
+	SlashingIndicatorAddress  = common.HexToAddress("0x0000000000000000000000000000000000001001")
+	SystemRewardAddress       = common.HexToAddress("0x0000000000000000000000000000000000001002")
+	StakingPoolAddress        = common.HexToAddress("0x0000000000000000000000000000000000007001")
+	GovernanceAddress         = common.HexToAddress("0x0000000000000000000000000000000000007002")
+	ChainConfigAddress        = common.HexToAddress("0x0000000000000000000000000000000000007003")
+	RuntimeUpgradeAddress     = common.HexToAddress("0x0000000000000000000000000000000000007004")
+	DeployerProxyAddress      = common.HexToAddress("0x0000000000000000000000000000000000007005")
+	TokenomicsAddress         = common.HexToAddress("0x0000000000000000000000000000000000007006")
+	IntermediarySystemAddress = common.HexToAddress("0xffffFFFfFFffffffffffffffFfFFFfffFFFfFFfE")
+)
@@ -0,0 +1,3 @@
+// Package systemcontracts contains Go bindings and addresses of the system contracts, bindings are generated
+// from forge artifacts by "make bindings" and verified by "make check-bindings".
+package systemcontracts
@@ -0,0 +1,342 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// stringListFlag collects values of the flag that can be specified several times
+type stringListFlag []string
+
+func (f *stringListFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *stringListFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+type upgradeProposalEntry struct {
+	Contract      string         `json:"contract"`
+	Address       common.Address `json:"address"`
+	OldCodeHash   common.Hash    `json:"oldCodeHash"`
+	NewCodeHash   common.Hash    `json:"newCodeHash"`
+	ApplyFunction hexutil.Bytes  `json:"applyFunction"`
+}
+
+// governanceProposal is an input of the governance's propose function
+type governanceProposal struct {
+	Targets     []common.Address `json:"targets"`
+	Values      []*hexutil.Big   `json:"values"`
+	Calldatas   []hexutil.Bytes  `json:"calldatas"`
+	Description string           `json:"description"`
+}
+
+// validate checks that every call of the proposal has target, value and calldata
+func (p *governanceProposal) validate() error {
+	if len(p.Targets) == 0 || len(p.Targets) != len(p.Values) || len(p.Targets) != len(p.Calldatas) {
+		return fmt.Errorf("proposal must have the same non-zero number of targets, values and calldatas")
+	}
+	return nil
+}
+
+// decodeUpgradeCall decodes system contract and its new runtime code from RuntimeUpgrade.upgradeSystemSmartContract call,
+// false is returned for other calls
+func decodeUpgradeCall(target common.Address, calldata []byte) (common.Address, []byte, bool) {
+	if target != runtimeUpgradeAddress || len(calldata) < 4 {
+		return common.Address{}, nil, false
+	}
+	method, err := mustParseArtifactABI(runtimeUpgradeRawArtifact).MethodById(calldata[:4])
+	if err != nil || method.Name != "upgradeSystemSmartContract" {
+		return common.Address{}, nil, false
+	}
+	args, err := method.Inputs.Unpack(calldata[4:])
+	if err != nil {
+		return common.Address{}, nil, false
+	}
+	return args[0].(common.Address), args[1].([]byte), true
+}
+
+// validateUpgrades checks that every upgrade entry matches its upgrade call, entries are informational and
+// may be out of sync with calls in the proposal edited by hand
+func (p *upgradeProposal) validateUpgrades() error {
+	newCodeHashes := make(map[common.Address]common.Hash)
+	for i, calldata := range p.Calldatas {
+		if contract, newCode, ok := decodeUpgradeCall(p.Targets[i], calldata); ok {
+			newCodeHashes[contract] = crypto.Keccak256Hash(newCode)
+		}
+	}
+	for _, u := range p.Upgrades {
+		hash, ok := newCodeHashes[u.Address]
+		if !ok {
+			return fmt.Errorf("upgrade of %s (%s) has no upgradeSystemSmartContract call", u.Contract, u.Address.Hex())
+		}
+		if hash != u.NewCodeHash {
+			return fmt.Errorf("new code hash of %s (%s) doesn't match its upgrade call", u.Contract, u.Address.Hex())
+		}
+	}
+	return nil
+}
+
+func (p *governanceProposal) callArgs() (values []*big.Int, calldatas [][]byte) {
+	for i := range p.Targets {
+		values = append(values, (*big.Int)(p.Values[i]))
+		calldatas = append(calldatas, p.Calldatas[i])
+	}
+	return values, calldatas
+}
+
+type upgradeProposal struct {
+	Upgrades []upgradeProposalEntry `json:"upgrades"`
+	// Warnings describe upgrades that may revert when the proposal is executed
+	Warnings []string `json:"warnings,omitempty"`
+	governanceProposal
+	DescriptionHash common.Hash `json:"descriptionHash"`
+	VotingPeriod    uint64      `json:"votingPeriod"`
+	// input data for the governance contract
+	Propose                       hexutil.Bytes `json:"propose"`
+	ProposeWithCustomVotingPeriod hexutil.Bytes `json:"proposeWithCustomVotingPeriod"`
+}
+
+// parseMigrationCall parses migration call in format "Contract=initEpochParams()" or "Contract=0x...",
+// where contract is a system contract name or address
+func parseMigrationCall(value string) (systemContract, []byte, error) {
+	parts := strings.SplitN(value, "=", 2)
+	if len(parts) != 2 {
+		return systemContract{}, nil, fmt.Errorf("migration call must be in format <contract>=<function or calldata>, got (%s)", value)
+	}
+	var contract systemContract
+	var ok bool
+	if common.IsHexAddress(parts[0]) {
+		contract, ok = systemContractByAddress(common.HexToAddress(parts[0]))
+	} else {
+		contract, ok = systemContractByName(parts[0])
+	}
+	if !ok {
+		return systemContract{}, nil, fmt.Errorf("unknown system contract (%s)", parts[0])
+	}
+	call := strings.TrimSpace(parts[1])
+	if strings.HasPrefix(call, "0x") {
+		input, err := hexutil.Decode(call)
+		if err != nil {
+			return systemContract{}, nil, fmt.Errorf("bad migration calldata for %s: %w", contract.Name, err)
+		}
+		return contract, input, nil
+	}
+	if !strings.HasSuffix(call, "()") {
+		return systemContract{}, nil, fmt.Errorf("migration function (%s) has arguments, pass encoded calldata instead", call)
+	}
+	return contract, crypto.Keccak256([]byte(call))[:4], nil
+}
+
+// runtimeUpgradeContracts are system contracts listed by RuntimeUpgrade.getSystemContracts() w/o registration,
+// other contracts can be upgraded only after they're registered with deploySystemSmartContract
+var runtimeUpgradeContracts = []common.Address{
+	stakingAddress,
+	slashingIndicatorAddress,
+	systemRewardAddress,
+	stakingPoolAddress,
+	governanceAddress,
+	chainConfigAddress,
+	runtimeUpgradeAddress,
+	deployerProxyAddress,
+}
+
+func runtimeUpgradeListsContract(address common.Address) bool {
+	for _, a := range runtimeUpgradeContracts {
+		if a == address {
+			return true
+		}
+	}
+	return false
+}
+
+type upgradeProposalConfig struct {
+	ArtifactsDir  string
+	Migrations    map[common.Address][]byte
+	OnlyContracts []string
+	Description   string
+	VotingPeriod  uint64
+	// KnownDirs are forge outputs of previous releases used to find storage layout of the deployed contracts
+	KnownDirs       []string
+	SkipLayoutCheck bool
+}
+
+func createUpgradeProposal(source *stateSource, config upgradeProposalConfig) (*upgradeProposal, error) {
+	runtimeUpgradeABI := mustParseArtifactABI(runtimeUpgradeRawArtifact)
+	governanceABI := mustParseArtifactABI(governanceRawArtifact)
+	for _, name := range config.OnlyContracts {
+		if _, ok := systemContractByName(name); !ok {
+			return nil, fmt.Errorf("unknown system contract (%s)", name)
+		}
+	}
+	proposal := &upgradeProposal{
+		VotingPeriod: config.VotingPeriod,
+	}
+	for _, contract := range systemContracts {
+		if len(config.OnlyContracts) > 0 {
+			found := false
+			for _, name := range config.OnlyContracts {
+				found = found || strings.EqualFold(name, contract.Name)
+			}
+			if !found {
+				continue
+			}
+		}
+		rawArtifact, err := readSystemContractArtifact(config.ArtifactsDir, contract)
+		if err != nil {
+			return nil, err
+		}
+		artifact, err := parseArtifact(rawArtifact)
+		if err != nil {
+			return nil, err
+		}
+		newCode := hexutil.MustDecode(artifact.DeployedBytecode)
+		account, ok := source.Alloc[contract.Address]
+		if !ok || len(account.Code) == 0 {
+			return nil, fmt.Errorf("system contract %s (%s) is not deployed in the state", contract.Name, contract.Address.Hex())
+		}
+		if bytes.Equal(account.Code, newCode) {
+			continue
+		}
+		if !config.SkipLayoutCheck {
+			issues, err := checkStorageLayoutCompatibility(contract, account.Code, rawArtifact, config.KnownDirs)
+			if err != nil {
+				return nil, err
+			}
+			for _, issue := range issues {
+				if issue.Fatal {
+					return nil, fmt.Errorf("storage layout of %s is not compatible: %s", contract.Name, issue.Message)
+				}
+			}
+		}
+		applyFunction := config.Migrations[contract.Address]
+		if applyFunction == nil {
+			applyFunction = []byte{}
+		}
+		calldata, err := runtimeUpgradeABI.Pack("upgradeSystemSmartContract", contract.Address, newCode, applyFunction)
+		if err != nil {
+			return nil, err
+		}
+		if !runtimeUpgradeListsContract(contract.Address) {
+			proposal.Warnings = append(proposal.Warnings, fmt.Sprintf("%s isn't listed by RuntimeUpgrade.getSystemContracts(), its upgrade reverts unless it's registered with deploySystemSmartContract", contract.Name))
+		}
+		proposal.Upgrades = append(proposal.Upgrades, upgradeProposalEntry{
+			Contract:      contract.Name,
+			Address:       contract.Address,
+			OldCodeHash:   crypto.Keccak256Hash(account.Code),
+			NewCodeHash:   crypto.Keccak256Hash(newCode),
+			ApplyFunction: applyFunction,
+		})
+		proposal.Targets = append(proposal.Targets, runtimeUpgradeAddress)
+		proposal.Values = append(proposal.Values, (*hexutil.Big)(big.NewInt(0)))
+		proposal.Calldatas = append(proposal.Calldatas, calldata)
+	}
+	// migration for the contract that isn't upgraded is most likely a mistake
+	for address := range config.Migrations {
+		found := false
+		for _, u := range proposal.Upgrades {
+			found = found || u.Address == address
+		}
+		if !found {
+			return nil, fmt.Errorf("migration call is specified for %s, but its bytecode is not changed", address.Hex())
+		}
+	}
+	if len(proposal.Upgrades) == 0 {
+		return proposal, nil
+	}
+	description := config.Description
+	if description == "" {
+		var names []string
+		for _, u := range proposal.Upgrades {
+			names = append(names, u.Contract)
+		}
+		description = fmt.Sprintf("Runtime upgrade for the smart contracts: %s", strings.Join(names, ", "))
+	}
+	proposal.Description = description
+	proposal.DescriptionHash = crypto.Keccak256Hash([]byte(description))
+	values, calldatas := proposal.callArgs()
+	var err error
+	proposal.Propose, err = governanceABI.Pack("propose", proposal.Targets, values, calldatas, description)
+	if err != nil {
+		return nil, err
+	}
+	proposal.ProposeWithCustomVotingPeriod, err = governanceABI.Pack("proposeWithCustomVotingPeriod", proposal.Targets, values, calldatas, description, new(big.Int).SetUint64(config.VotingPeriod))
+	if err != nil {
+		return nil, err
+	}
+	return proposal, nil
+}
+
+func runUpgradeProposalCommand(args []string) error {
+	flags := flag.NewFlagSet("upgrade-proposal", flag.ContinueOnError)
+	stateFile := flags.String("state", "", "genesis file or state dump of the target network")
+	artifactsDir := flags.String("artifacts", "", "forge output directory with new artifacts (embedded artifacts are used by default)")
+	description := flags.String("description", "", "proposal description")
+	votingPeriod := flags.Uint64("voting-period", 0, "custom voting period for proposeWithCustomVotingPeriod (in blocks)")
+	format := flags.String("format", "json", "output format: json or hex (input of proposeWithCustomVotingPeriod)")
+	outputFile := flags.String("output", "stdout", "output file")
+	skipLayoutCheck := flags.Bool("skip-layout-check", false, "don't verify storage layout compatibility (unsafe)")
+	var migrationCalls, onlyContracts, knownDirs stringListFlag
+	flags.Var(&migrationCalls, "migration", "migration call for the contract, e.g. ChainConfig=initEpochParams() (can be repeated)")
+	flags.Var(&onlyContracts, "contract", "upgrade only specified system contract (can be repeated)")
+	flags.Var(&knownDirs, "known", "forge output directory of the previous release (can be repeated)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *stateFile == "" {
+		return fmt.Errorf("state file is required")
+	}
+	source, err := readStateSource(*stateFile)
+	if err != nil {
+		return err
+	}
+	migrations := make(map[common.Address][]byte)
+	for _, value := range migrationCalls {
+		contract, input, err := parseMigrationCall(value)
+		if err != nil {
+			return err
+		}
+		if _, ok := migrations[contract.Address]; ok {
+			return fmt.Errorf("migration call of %s is specified more than once", contract.Name)
+		}
+		migrations[contract.Address] = input
+	}
+	proposal, err := createUpgradeProposal(source, upgradeProposalConfig{
+		ArtifactsDir:    *artifactsDir,
+		Migrations:      migrations,
+		OnlyContracts:   onlyContracts,
+		Description:     *description,
+		VotingPeriod:    *votingPeriod,
+		KnownDirs:       knownDirs,
+		SkipLayoutCheck: *skipLayoutCheck,
+	})
+	if err != nil {
+		return err
+	}
+	if len(proposal.Upgrades) == 0 {
+		return fmt.Errorf("runtime code of system contracts is not changed, nothing to propose")
+	}
+	// warnings go to stderr, since the proposal may be written to stdout
+	for _, warning := range proposal.Warnings {
+		fmt.Fprintf(os.Stderr, "WARN: %s\n", warning)
+	}
+	switch *format {
+	case "json":
+		result, _ := json.MarshalIndent(proposal, "", "  ")
+		return writeOutputFile(*outputFile, append(result, '\n'))
+	case "hex":
+		return writeOutputFile(*outputFile, []byte(hexutil.Encode(proposal.ProposeWithCustomVotingPeriod)+"\n"))
+	}
+	return fmt.Errorf("unknown output format (%s)", *format)
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+)
+
+// ordering of genesis validators in extraData and Staking ctor
+const (
+	// validators must be listed in ascending order (default)
+	validatorOrderingStrict = "strict"
+	// validators are sorted in ascending order with a warning
+	validatorOrderingSort = "sort"
+	// validators are kept in config order and violations are reported as warnings, it's required for networks
+	// launched before the check since their genesis hash depends on the order
+	validatorOrderingLegacy = "legacy"
+)
+
+func sortValidators(validators []common.Address) []common.Address {
+	result := append([]common.Address{}, validators...)
+	sort.Slice(result, func(i, j int) bool {
+		return bytes.Compare(result[i].Bytes(), result[j].Bytes()) < 0
+	})
+	return result
+}
+
+func sameValidators(a, b []common.Address) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	a, b = sortValidators(a), sortValidators(b)
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// canonicalValidatorSet applies validator ordering of the config and checks validators against active validators length,
+// violations tolerated by the ordering mode are returned as warnings
+func canonicalValidatorSet(config genesisConfig) ([]common.Address, []string, error) {
+	var warnings []string
+	validators := config.Validators
+	seen := make(map[common.Address]bool)
+	for _, v := range validators {
+		if seen[v] {
+			return nil, nil, fmt.Errorf("duplicated validator (%s)", v.Hex())
+		}
+		seen[v] = true
+	}
+	if sorted := sortValidators(validators); !sameOrder(validators, sorted) {
+		switch config.ValidatorOrdering {
+		case "", validatorOrderingStrict:
+			return nil, nil, fmt.Errorf("validators are not in canonical (ascending) order, expected order is %s", formatAddresses(sorted))
+		case validatorOrderingSort:
+			warnings = append(warnings, "validators are not in canonical (ascending) order, sorting them")
+			validators = sorted
+		case validatorOrderingLegacy:
+			warnings = append(warnings, "validators are not in canonical (ascending) order, config order is kept (legacy)")
+		default:
+			return nil, nil, fmt.Errorf("unknown validator ordering (%s)", config.ValidatorOrdering)
+		}
+	}
+	if uint32(len(validators)) > config.ConsensusParams.ActiveValidatorsLength {
+		message := fmt.Sprintf("there are %d genesis validators, but active validators length is %d", len(validators), config.ConsensusParams.ActiveValidatorsLength)
+		if config.ValidatorOrdering != validatorOrderingLegacy {
+			return nil, nil, errors.New(message)
+		}
+		warnings = append(warnings, message)
+	}
+	return validators, warnings, nil
+}
+
+func sameOrder(a, b []common.Address) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return len(a) == len(b)
+}
+
+func formatAddresses(addresses []common.Address) string {
+	var result []string
+	for _, a := range addresses {
+		result = append(result, a.Hex())
+	}
+	return fmt.Sprintf("%v", result)
+}
+
+// checkGenesisActiveValidators verifies that extraData validators are the active set reported by the genesis Staking contract
+func checkGenesisActiveValidators(genesis *core.Genesis, ordering string) ([]string, error) {
+	extraValidators, _, err := parseExtraDataValidators(genesis.ExtraData, genesis.Config.IsLuban(common.Big0))
+	if err != nil {
+		return nil, err
+	}
+	env, err := newSimulationEnv(genesis.Config, genesis.Alloc, 1, genesis.Timestamp+simulationBlockPeriod(genesis.Config))
+	if err != nil {
+		return nil, err
+	}
+	if err := env.initSystemContracts(); err != nil {
+		return nil, err
+	}
+	result, err := env.staticCall(stakingAddress, mustParseArtifactABI(stakingRawArtifact), "getValidators")
+	if err != nil {
+		return nil, err
+	}
+	activeValidators := result[0].([]common.Address)
+	if sameValidators(extraValidators, activeValidators) {
+		return nil, nil
+	}
+	message := fmt.Sprintf("extra data validators %s don't match Staking active validators %s", formatAddresses(extraValidators), formatAddresses(activeValidators))
+	if ordering == validatorOrderingLegacy {
+		return []string{message}, nil
+	}
+	return nil, errors.New(message)
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core"
+)
+
+// systemContractVerification isn't deployed if the contract is missing in the genesis alloc (e.g. it's added after
+// the network launch), such contract has no code hash and release
+type systemContractVerification struct {
+	Name     string         `json:"name"`
+	Address  common.Address `json:"address"`
+	Deployed bool           `json:"deployed"`
+	// CodeHash is a hash of the runtime code w/o metadata
+	CodeHash *common.Hash `json:"codeHash,omitempty"`
+	Release  string       `json:"release,omitempty"`
+	// MatchesArtifact is true if the code is the same as deployedBytecode of the current artifact (metadata is ignored)
+	MatchesArtifact bool `json:"matchesArtifact"`
+}
+
+// verifySystemContracts labels system contracts of the genesis with releases from the code registry
+func verifySystemContracts(genesis *core.Genesis) ([]systemContractVerification, error) {
+	var result []systemContractVerification
+	for _, c := range systemContracts {
+		entry := systemContractVerification{Name: c.Name, Address: c.Address}
+		account, ok := genesis.Alloc[c.Address]
+		if ok && len(account.Code) > 0 {
+			artifact, err := parseArtifact(c.RawArtifact)
+			if err != nil {
+				return nil, err
+			}
+			hash := runtimeCodeHash(account.Code)
+			entry.Deployed, entry.CodeHash, entry.Release = true, &hash, knownCodeReleases.release(c.Name, account.Code)
+			entry.MatchesArtifact = bytes.Equal(stripCodeMetadata(account.Code), stripCodeMetadata(hexutil.MustDecode(artifact.DeployedBytecode)))
+		}
+		result = append(result, entry)
+	}
+	return result, nil
+}
+
+func runVerifyCommand(args []string) error {
+	flags := flag.NewFlagSet("verify", flag.ContinueOnError)
+	genesisFile := flags.String("genesis", "", "genesis file (built-in network's genesis by default)")
+	networkName := flags.String("network", "", "built-in network: localnet, devnet, testnet, spicy or mainnet")
+	allowUnknown := flags.Bool("allow-unknown", false, "don't fail if code of a system contract isn't found in the registry")
+	requireDeployed := flags.Bool("require-deployed", false, "fail if a system contract isn't deployed in the genesis (by default it's only reported)")
+	format := flags.String("format", "table", "output format: table or json")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *networkName != "" && *genesisFile == "" {
+		network, ok := builtinNetworkByName(*networkName)
+		if !ok {
+			return fmt.Errorf("unknown network (%s)", *networkName)
+		}
+		*genesisFile = network.GenesisFile
+	}
+	if *genesisFile == "" {
+		return fmt.Errorf("genesis file or network is required")
+	}
+	fileContents, err := os.ReadFile(*genesisFile)
+	if err != nil {
+		return err
+	}
+	genesis := &core.Genesis{}
+	if err := json.Unmarshal(fileContents, genesis); err != nil {
+		return fmt.Errorf("failed to parse genesis file (%s): %w", *genesisFile, err)
+	}
+	verifications, err := verifySystemContracts(genesis)
+	if err != nil {
+		return err
+	}
+	switch *format {
+	case "json":
+		result, _ := json.MarshalIndent(verifications, "", "  ")
+		os.Stdout.Write(append(result, '\n'))
+	case "table":
+		fmt.Printf("%-20s %-44s %-68s %-20s %s\n", "system contract", "address", "code hash", "release", "current artifact")
+		for _, v := range verifications {
+			if !v.Deployed {
+				fmt.Printf("%-20s %-44s %-68s %-20s %s\n", v.Name, v.Address.Hex(), "not deployed", "-", "-")
+				continue
+			}
+			fmt.Printf("%-20s %-44s %-68s %-20s %t\n", v.Name, v.Address.Hex(), v.CodeHash.Hex(), v.Release, v.MatchesArtifact)
+		}
+	default:
+		return fmt.Errorf("unknown output format (%s)", *format)
+	}
+	var issues []string
+	for _, v := range verifications {
+		switch {
+		case !v.Deployed:
+			if *requireDeployed {
+				issues = append(issues, fmt.Sprintf("%s (%s) is not deployed", v.Name, v.Address.Hex()))
+			}
+		case v.Release == unknownRelease && !*allowUnknown:
+			issues = append(issues, fmt.Sprintf("%s (%s) runs unknown code %s", v.Name, v.Address.Hex(), v.CodeHash.Hex()))
+		}
+	}
+	if len(issues) > 0 {
+		return fmt.Errorf("verification failed:\n - %s", strings.Join(issues, "\n - "))
+	}
+	return nil
+}